@@ -0,0 +1,69 @@
+package stream
+
+import "sync"
+
+// fold fold all elements of s into one accumulator, async streamer folds with parallel workers,
+// each worker folds into its own accumulator built by init and accumulators are merged at last
+func fold[T, A any](s Streamer[T], init func() A, add func(A, T) A, merge func(A, A) A) A {
+	switch s := s.(type) {
+	case *asyncStreamer[T]:
+		return asyncFold(s, init, add, merge)
+	case *streamer[T]:
+		source, rec := s.run()
+		acc := init()
+		if !s.finite(rec, source) {
+			return acc
+		}
+		next := s.pull(source)
+		for t, ok := next(); ok; t, ok = next() {
+			acc = add(acc, t)
+		}
+		return acc
+	default:
+		acc := init()
+		for _, t := range s.ToSlice() {
+			acc = add(acc, t)
+		}
+		return acc
+	}
+}
+
+func asyncFold[T, A any](s *asyncStreamer[T], init func() A, add func(A, T) A, merge func(A, A) A) A {
+	workers := s.parallelSize
+	if workers <= 0 {
+		workers = 1
+	}
+
+	rec := s.begin()
+	if !s.finite(rec) {
+		return init()
+	}
+
+	var wg sync.WaitGroup
+	ch, stop := s.start(rec)
+	defer stop()
+
+	accs := make([]A, workers)
+	for i := range accs {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			acc := init()
+			for batch := range ch {
+				for _, t := range batch {
+					if !s.cancelled() {
+						acc = add(acc, t)
+					}
+				}
+			}
+			accs[i] = acc
+		}(i)
+	}
+	wg.Wait()
+
+	result := accs[0]
+	for _, acc := range accs[1:] {
+		result = merge(result, acc)
+	}
+	return result
+}
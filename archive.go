@@ -0,0 +1,144 @@
+package stream
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"sync"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// ErrEntryExpired archive entry is read after stream advanced
+var ErrEntryExpired = errors.New("archive entry expired")
+
+// ArchiveEntry member of tar or zip archive.
+// Body of tar member is valid only until the stream advances, so read it in a sequential stage before Parallel.
+type ArchiveEntry struct {
+	// Name path of member in archive
+	Name string
+	// Info header of member
+	Info fs.FileInfo
+
+	open func() (io.ReadCloser, error)
+}
+
+// Open open body of member
+func (e ArchiveEntry) Open() (io.ReadCloser, error) { return e.open() }
+
+// TarEntries create a new stream of members of tar archive in r, read lazily.
+// Gzip compressed archive is decoded transparently, errors are reported by Err.
+func TarEntries(r io.Reader) Streamer[ArchiveEntry] {
+	s := newStreamer[ArchiveEntry](nil)
+	t := &tarSource{r: r, rec: recorderOf(s.ctx)}
+	s.source = newSupplyIter(t.next, sizeUnknown)
+	return s
+}
+
+// tarSource read members of tar archive, body of member expires once the stream advances
+type tarSource struct {
+	mu  sync.Mutex
+	r   io.Reader
+	rec *errRecorder
+
+	tr         *tar.Reader
+	closer     io.Closer
+	done       bool
+	generation int64 // number of advances, body of member read at earlier generation is expired
+}
+
+func (t *tarSource) next() (entry ArchiveEntry, ok bool) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+
+	if t.done {
+		return entry, false
+	}
+	if t.tr == nil { // open reader lazily
+		in, c, err := decompress(t.r)
+		if err != nil {
+			t.stop(fmt.Errorf("open tar: %w", err))
+			return entry, false
+		}
+		t.tr, t.closer = tar.NewReader(in), c
+	}
+
+	t.generation++
+	header, err := t.tr.Next() // unread body of previous member is discarded
+	if err == io.EOF {
+		t.stop(nil)
+		return entry, false
+	}
+	if err != nil {
+		t.stop(fmt.Errorf("read tar: %w", err))
+		return entry, false
+	}
+
+	current := t.generation
+	return ArchiveEntry{Name: header.Name, Info: header.FileInfo(), open: func() (io.ReadCloser, error) {
+		t.mu.Lock()
+		defer t.mu.Unlock()
+		if current != t.generation {
+			return nil, fmt.Errorf("open %s: %w", header.Name, ErrEntryExpired)
+		}
+		return io.NopCloser(&tarBody{src: t, generation: current}), nil
+	}}, true
+}
+
+// stop stop reading archive and record err if not nil, must be called with lock held
+func (t *tarSource) stop(err error) {
+	if t.done = true; err != nil {
+		t.rec.record(err)
+	}
+	if t.closer != nil {
+		_ = t.closer.Close()
+	}
+}
+
+// tarBody body of current tar member, fail once stream advanced
+type tarBody struct {
+	src        *tarSource
+	generation int64
+}
+
+func (b *tarBody) Read(p []byte) (int, error) {
+	b.src.mu.Lock()
+	defer b.src.mu.Unlock()
+	if b.generation != b.src.generation {
+		return 0, ErrEntryExpired
+	}
+	return b.src.tr.Read(p)
+}
+
+// ZipEntries create a new stream of members of zip archive in r of size, central directory is read when stream runs.
+// Errors are reported by Err.
+func ZipEntries(r io.ReaderAt, size int64) Streamer[ArchiveEntry] {
+	s := newStreamer[ArchiveEntry](nil)
+	rec := recorderOf(s.ctx)
+
+	s.source = newGenIter(func() types.Supplier[ArchiveEntry] {
+		var files []*zip.File
+		var opened bool
+		return func() (entry ArchiveEntry, ok bool) {
+			if !opened {
+				opened = true
+				zr, err := zip.NewReader(r, size)
+				if err != nil {
+					rec.record(fmt.Errorf("open zip: %w", err))
+					return entry, false
+				}
+				files = zr.File
+			}
+			if len(files) == 0 {
+				return entry, false
+			}
+			f := files[0]
+			files = files[1:]
+			return ArchiveEntry{Name: f.Name, Info: f.FileInfo(), open: f.Open}, true
+		}
+	}, sizeUnknown)
+	return s
+}
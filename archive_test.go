@@ -0,0 +1,104 @@
+package stream_test
+
+import (
+	"archive/tar"
+	"archive/zip"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+var archiveFiles = []struct{ name, body string }{
+	{"logs/a.log", "a1\na2\n"},
+	{"logs/b.log", "b1\n"},
+}
+
+func readEntry(e stream.ArchiveEntry) string {
+	rc, err := e.Open()
+	if err != nil {
+		return err.Error()
+	}
+	defer rc.Close()
+	return strings.Join(stream.Lines(rc).ToSlice(), ",")
+}
+
+func TestTarEntries(t *testing.T) {
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	tw := tar.NewWriter(zw)
+	for _, f := range archiveFiles {
+		_ = tw.WriteHeader(&tar.Header{Name: f.name, Mode: 0o644, Size: int64(len(f.body))})
+		_, _ = tw.Write([]byte(f.body))
+	}
+	_ = tw.Close()
+	_ = zw.Close()
+
+	var bodies []string
+	stream.TarEntries(bytes.NewReader(buf.Bytes())).ForEach(func(e stream.ArchiveEntry) { bodies = append(bodies, e.Name+":"+readEntry(e)) })
+	if !reflect.DeepEqual(bodies, []string{"logs/a.log:a1,a2", "logs/b.log:b1"}) {
+		t.Errorf("expect bodies of two members, got %v", bodies)
+	}
+
+	entries := stream.TarEntries(bytes.NewReader(buf.Bytes())).ToSlice()
+	if len(entries) != 2 || entries[1].Info.Size() != 3 {
+		t.Errorf("expect 2 entries, got %d", len(entries))
+	}
+	for _, e := range entries {
+		if _, err := e.Open(); !errors.Is(err, stream.ErrEntryExpired) {
+			t.Errorf("%s: expect ErrEntryExpired once stream advanced, got %v", e.Name, err)
+		}
+	}
+
+	// bodies are read in sequential stages between source and Parallel
+	filtered := stream.TarEntries(bytes.NewReader(buf.Bytes())).
+		Filter(func(e stream.ArchiveEntry) bool { return strings.HasSuffix(e.Name, "b.log") }).
+		Convert(func(e stream.ArchiveEntry) any { return e.Name + ":" + readEntry(e) }).
+		Parallel(2).ToSlice()
+	if !reflect.DeepEqual(filtered, []any{"logs/b.log:b1"}) {
+		t.Errorf("expect body of filtered member read before Parallel, got %v", filtered)
+	}
+
+	// body is expired once read after stream advanced past it
+	var held io.ReadCloser
+	stream.TarEntries(bytes.NewReader(buf.Bytes())).ForEach(func(e stream.ArchiveEntry) {
+		if held == nil {
+			held, _ = e.Open()
+		}
+	})
+	if _, err := held.Read(make([]byte, 1)); !errors.Is(err, stream.ErrEntryExpired) {
+		t.Errorf("expect ErrEntryExpired reading body after stream advanced, got %v", err)
+	}
+
+	broken := stream.TarEntries(strings.NewReader(strings.Repeat("x", 1024)))
+	if count := broken.Count(); count != 0 || broken.Err() == nil {
+		t.Errorf("expect error on broken archive, got %d %v", count, broken.Err())
+	}
+}
+
+func TestZipEntries(t *testing.T) {
+	var buf bytes.Buffer
+	zw := zip.NewWriter(&buf)
+	for _, f := range archiveFiles {
+		w, _ := zw.Create(f.name)
+		_, _ = io.WriteString(w, f.body)
+	}
+	_ = zw.Close()
+
+	s := stream.ZipEntries(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	var bodies []string
+	for _, e := range s.ToSlice() {
+		bodies = append(bodies, e.Name+":"+readEntry(e))
+	}
+	if !reflect.DeepEqual(bodies, []string{"logs/a.log:a1,a2", "logs/b.log:b1"}) {
+		t.Errorf("expect bodies of two members, got %v", bodies)
+	}
+	if count := s.Count(); count != 2 {
+		t.Errorf("expect reusable stream of 2 entries, got %d", count)
+	}
+}
@@ -2,8 +2,10 @@ package stream
 
 import (
 	"context"
-
-	"github.com/tr1v3r/pkg/pools"
+	"io"
+	"math/rand"
+	"sync"
+	"time"
 
 	"github.com/tr1v3r/stream/types"
 )
@@ -13,31 +15,102 @@ var (
 	_ Streamer[float64] = newAsyncStreamer[float64](1, nil)
 )
 
-type asyncStage[T any] func() <-chan T
+// asyncStage start stage sending results in batches to returned channel, stage stops once ctx done.
+// batchSize is the number of elements a source puts in one batch, stages keep batches of their input.
+type asyncStage[T any] func(ctx context.Context, batchSize int) <-chan []T
 
 func newAsyncStreamer[T any](parallelSize int, ch <-chan T) *asyncStreamer[T] {
-	return &asyncStreamer[T]{ctx: ctx, parallelSize: parallelSize, stage: func() <-chan T { return ch }}
+	return &asyncStreamer[T]{ctx: ctx, asyncOptions: asyncOptions{parallelSize: parallelSize}, stage: func(ctx context.Context, batchSize int) <-chan []T {
+		return batchOf(ctx, ch, batchSize)
+	}, last: new(lastRun)}
+}
+
+func wrapAsyncStreamer[T any](opts asyncOptions, stage asyncStage[T]) *asyncStreamer[T] {
+	return &asyncStreamer[T]{ctx: ctx, asyncOptions: opts, stage: stage, last: new(lastRun)}
 }
 
-func wrapAsyncStreamer[T any](parallelSize int, stage asyncStage[T]) *asyncStreamer[T] {
-	return &asyncStreamer[T]{ctx: ctx, parallelSize: parallelSize, stage: stage}
+// asyncOptions options of async stages
+type asyncOptions struct {
+	parallelSize int      // worker number of each stage
+	batchSize    int      // elements sent through channels at once, 0 or 1 means one by one
+	executor     Executor // nil means workers run the work themselves
+	infinite     bool     // source never exhausts
 }
 
 // asyncStreamer underlying p streamer implement for Streamer
 type asyncStreamer[T any] struct {
 	ctx context.Context
 
-	parallelSize int
-	stage        asyncStage[T]
+	asyncOptions
+	stage asyncStage[T]
+	last  *lastRun // recorder of the latest run, reported by Err
 }
 
 func (s asyncStreamer[T]) WithContext(ctx context.Context) Streamer[T] {
-	s.ctx = ctx
+	s.ctx, s.last = inheritValues(ctx, s.ctx), new(lastRun)
+	return &s
+}
+
+func (s asyncStreamer[T]) WithExecutor(executor Executor) Streamer[T] {
+	s.executor = executor
+	return &s
+}
+
+func (s asyncStreamer[T]) WithBatchSize(size int) Streamer[T] {
+	s.batchSize = size
+	return &s
+}
+
+func (s asyncStreamer[T]) WithRand(src rand.Source) Streamer[T] {
+	s.ctx, s.last = withRand(s.ctx, src), new(lastRun)
 	return &s
 }
 
 func (s *asyncStreamer[T]) cancelled() bool { return s.ctx.Err() != nil }
 
+// begin return recorder of a new run reported by Err
+func (s *asyncStreamer[T]) begin() *errRecorder {
+	rec := newRunRecorder(s.ctx)
+	s.last.Store(rec)
+	return rec
+}
+
+// finite report whether consuming all elements of stream ends,
+// ErrInfiniteStream is recorded to rec for known infinite source without a cancellable context
+func (s *asyncStreamer[T]) finite(rec *errRecorder) bool {
+	if s.infinite && s.ctx.Done() == nil {
+		rec.record(ErrInfiniteStream)
+		return false
+	}
+	return true
+}
+
+// start start stage of run recording errors to rec on context cancelled by stop,
+// which must be called once consuming ends, so that stage goroutines exit even though results are left
+func (s *asyncStreamer[T]) start(rec *errRecorder) (ch <-chan []T, stop context.CancelFunc) {
+	ctx, cancel := context.WithCancel(withRunRecorder(s.ctx, rec))
+	return s.stage(ctx, s.batchSize), cancel
+}
+
+// pull return function pulling stage results one by one until stage finished or stream cancelled,
+// and function stopping stage
+func (s *asyncStreamer[T]) pull(rec *errRecorder) (next func() (T, bool), stop context.CancelFunc) {
+	ch, stop := s.start(rec)
+	var batch []T
+	return func() (t T, ok bool) {
+		for len(batch) == 0 {
+			if s.cancelled() {
+				return t, false
+			}
+			if batch, ok = <-ch; !ok {
+				return t, false
+			}
+		}
+		t, batch = batch[0], batch[1:]
+		return t, true
+	}, stop
+}
+
 func (s *asyncStreamer[T]) Append(data ...T) Streamer[T] { return s.sync().Append(data...) }
 func (s *asyncStreamer[T]) Execute() Streamer[T]         { return s.sync().Execute() }
 func (s *asyncStreamer[T]) Parallel(n int) Streamer[T] {
@@ -49,75 +122,130 @@ func (s *asyncStreamer[T]) Parallel(n int) Streamer[T] {
 }
 
 func (s *asyncStreamer[T]) Filter(judge types.Judge[T]) Streamer[T] {
-	return wrapAsyncStreamer(s.parallelSize, s.wrapAsyncStage(func(t T, ch chan<- T) {
-		if judge(t) {
-			ch <- t
-		}
-	})).WithContext(s.ctx)
+	return s.filterWith(func() types.Judge[T] { return judge })
+}
+
+// filterWith filter data by Judge built for each run, for judges holding state
+func (s *asyncStreamer[T]) filterWith(newJudge func() types.Judge[T]) Streamer[T] {
+	return wrapAsyncStreamer(s.asyncOptions, func(ctx context.Context, batchSize int) <-chan []T {
+		judge := newJudge()
+		return runWorkers(ctx, s.stage(ctx, batchSize), s.asyncOptions, func(t T) (T, bool) { return t, judge(t) })
+	}).WithContext(s.ctx)
 }
 func (s *asyncStreamer[T]) Map(m types.Mapper[T]) Streamer[T] {
-	return wrapAsyncStreamer(s.parallelSize, s.wrapAsyncStage(func(t T, ch chan<- T) {
-		ch <- m(t)
+	return wrapAsyncStreamer(s.asyncOptions, s.wrapAsyncStage(func(t T) (T, bool) {
+		return m(t), true
 	})).WithContext(s.ctx)
 }
 func (s *asyncStreamer[T]) Peek(consumer types.Consumer[T]) Streamer[T] {
-	return wrapAsyncStreamer(s.parallelSize, s.wrapAsyncStage(func(t T, ch chan<- T) {
+	return wrapAsyncStreamer(s.asyncOptions, s.wrapAsyncStage(func(t T) (T, bool) {
 		consumer(t)
-		ch <- t
+		return t, true
 	})).WithContext(s.ctx)
 }
 
+func (s *asyncStreamer[T]) SampleRate(p float64) Streamer[T] {
+	r := randOf(s.ctx)
+	return s.Filter(func(T) bool { return r.Float64() < p })
+}
+
 func (s *asyncStreamer[T]) Convert(convert types.Converter[T, any]) Streamer[any] {
-	return wrapAsyncStreamer(s.parallelSize, func() <-chan any {
-		ch := make(chan any, 1024)
-		go func(size int) {
-			defer close(ch)
-			pool := pools.NewPool(size)
-			for t := range s.stage() {
-				pool.Wait()
-				go func(t T) {
-					defer pool.Done()
-					ch <- convert(t)
-				}(t)
-			}
-			pool.WaitAll()
-		}(s.parallelSize)
-		return ch
+	return wrapAsyncStreamer(s.asyncOptions, func(ctx context.Context, batchSize int) <-chan []any {
+		return runWorkers(ctx, s.stage(ctx, batchSize), s.asyncOptions, func(t T) (any, bool) { return convert(t), true })
 	}).WithContext(s.ctx)
 }
 
-func (s *asyncStreamer[T]) Distinct() Streamer[T] { return s.Filter(distinctJudge[T]()) }
+func (s *asyncStreamer[T]) Distinct() Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctJudge[T](s.parallelSize) })
+}
+func (s *asyncStreamer[T]) DistinctWithin(n int) Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctWithinJudge[T](n) })
+}
+func (s *asyncStreamer[T]) DistinctTTL(ttl time.Duration, opts ...DistinctTTLOptions) Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctTTLJudge[T](ttl, opts...) })
+}
+func (s *asyncStreamer[T]) DistinctApprox(expectedItems int, fpRate float64) Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctApproxJudge[T](expectedItems, fpRate) })
+}
 func (s *asyncStreamer[T]) Sort(comparator types.Comparator[T]) Streamer[T] {
 	return s.sync().Sort(comparator)
 }
+func (s *asyncStreamer[T]) SortStable(comparator types.Comparator[T]) Streamer[T] {
+	return s.sync().SortStable(comparator)
+}
 func (s *asyncStreamer[T]) ReverseSort(comparator types.Comparator[T]) Streamer[T] {
 	return s.sync().ReverseSort(comparator)
 }
-func (s *asyncStreamer[T]) Reverse() Streamer[T]      { return s.sync().Reverse() }
-func (s *asyncStreamer[T]) Limit(l int64) Streamer[T] { return s.sync().Limit(l) }
-func (s *asyncStreamer[T]) Skip(n int64) Streamer[T]  { return s.sync().Skip(n) }
+func (s *asyncStreamer[T]) ExternalSort(comparator types.Comparator[T], opts ExternalSortOptions) Streamer[T] {
+	return wrapStreamer(newIterator[T](nil), func(rec *errRecorder, iter iterator[T]) iterator[T] {
+		if !s.finite(rec) {
+			return iter
+		}
+		next, stop := s.pull(rec)
+		defer stop()
+		return externalSort(s.ctx, rec, next, comparator, opts)
+	}).WithContext(s.ctx)
+}
+func (s *asyncStreamer[T]) Reverse() Streamer[T] { return s.sync().Reverse() }
+func (s *asyncStreamer[T]) Limit(l int64) Streamer[T] {
+	return wrapStreamer(newIterator[T](nil), func(rec *errRecorder, iter iterator[T]) iterator[T] {
+		next, stop := s.pull(rec)
+		defer stop()
+
+		results := []T{}
+		for i := int64(0); i < l; i++ {
+			t, ok := next()
+			if !ok {
+				break
+			}
+			results = append(results, t)
+		}
+		return iter.Concat(newIterator(results))
+	}).WithContext(s.ctx)
+}
+func (s *asyncStreamer[T]) Skip(n int64) Streamer[T] {
+	return wrapAsyncStreamer(s.asyncOptions, func(ctx context.Context, batchSize int) <-chan []T {
+		in, out := s.stage(ctx, batchSize), make(chan []T, bufferOf(batchSize))
+		go func() {
+			defer close(out)
+
+			left := n // drop elements as they come, nothing is buffered
+			for batch := range in {
+				if left > 0 {
+					drop := min(left, int64(len(batch)))
+					batch, left = batch[drop:], left-drop
+				}
+				if len(batch) > 0 && !send(ctx, out, batch) {
+					return
+				}
+			}
+		}()
+		return out
+	}).WithContext(s.ctx)
+}
 func (s *asyncStreamer[T]) Pick(start, end, interval int) Streamer[T] {
+	if end >= 0 { // elements after end are never needed
+		return s.Limit(int64(end)+1).Pick(start, end, interval)
+	}
 	return s.sync().Pick(start, end, interval)
 }
 
 func (s *asyncStreamer[T]) Collect(to types.Collector[T]) any { return s.sync().Collect(to) }
 
 func (s *asyncStreamer[T]) ForEach(consumer types.Consumer[T]) {
-	pool := pools.NewPool(s.parallelSize)
-	for t := range s.stage() {
-		if s.cancelled() {
-			return
-		}
+	ch, stop := s.start(s.begin())
+	defer stop()
 
-		pool.Wait()
-		go func(t T) {
-			defer pool.Done()
+	done := runWorkers(s.ctx, ch, s.asyncOptions, func(t T) (struct{}, bool) {
+		if !s.cancelled() {
 			consumer(t)
-		}(t)
+		}
+		return struct{}{}, false
+	})
+	for range done { // wait all workers exit
 	}
-	pool.WaitAll()
 }
-func (s *asyncStreamer[T]) ToSlice() []T { return s.fetchAll() }
+func (s *asyncStreamer[T]) ToSlice() []T { return s.fetchAll(s.begin()) }
 
 func (s *asyncStreamer[T]) AllMatch(judge types.Judge[T]) bool {
 	return s.match(func(t T) bool { return !judge(t) }, false)
@@ -129,9 +257,14 @@ func (s *asyncStreamer[T]) AnyMatch(judge types.Judge[T]) bool {
 	return s.match(judge, true)
 }
 func (s *asyncStreamer[T]) match(judge types.Judge[T], result bool) bool {
-	for t := range s.stage() {
-		if s.cancelled() || judge(t) {
-			return result
+	ch, stop := s.start(s.begin())
+	defer stop()
+
+	for batch := range ch {
+		for _, t := range batch {
+			if s.cancelled() || judge(t) {
+				return result
+			}
 		}
 	}
 	return !result
@@ -142,18 +275,36 @@ func (s *asyncStreamer[T]) Reduce(accumulator types.BinaryOperator[T]) (result T
 }
 func (s *asyncStreamer[T]) ReduceFrom(initValue T, accumulator types.BinaryOperator[T]) T {
 	result := initValue
-	for t := range s.stage() {
-		if s.cancelled() {
-			return result
+	rec := s.begin()
+	if !s.finite(rec) {
+		return result
+	}
+
+	ch, stop := s.start(rec)
+	defer stop()
+	for batch := range ch {
+		for _, t := range batch {
+			if s.cancelled() {
+				return result
+			}
+			result = accumulator(result, t)
 		}
-		result = accumulator(result, t)
 	}
 	return result
 }
 func (s *asyncStreamer[T]) ReduceWith(initValue any, accumulator types.Accumulator[T, any]) any {
 	result := initValue
-	for t := range s.stage() {
-		result = accumulator(result, t)
+	rec := s.begin()
+	if !s.finite(rec) {
+		return result
+	}
+
+	ch, stop := s.start(rec)
+	defer stop()
+	for batch := range ch {
+		for _, t := range batch {
+			result = accumulator(result, t)
+		}
 	}
 	return result
 }
@@ -161,47 +312,244 @@ func (s *asyncStreamer[T]) ReduceBy(initValueBulider func(sizeMayNegative int) a
 	return s.sync().ReduceBy(initValueBulider, accumulator)
 }
 
-func (s *asyncStreamer[T]) First() T { return <-s.stage() }
-func (s *asyncStreamer[T]) Take() T {
-	data := s.fetchAll()
-	return data[seededRand.Intn(len(data))]
+func (s *asyncStreamer[T]) First() T { t, _ := s.FirstOK(); return t }
+func (s *asyncStreamer[T]) Take() T  { t, _ := s.TakeOK(); return t }
+func (s *asyncStreamer[T]) Any() T   { return s.Take() }
+func (s *asyncStreamer[T]) Last() T  { t, _ := s.LastOK(); return t }
+func (s *asyncStreamer[T]) FirstOK() (T, bool) {
+	next, stop := s.pull(s.begin())
+	defer stop()
+	return next()
+}
+func (s *asyncStreamer[T]) TakeOK() (t T, ok bool) {
+	rec := s.begin()
+	if !s.finite(rec) {
+		return t, false
+	}
+	next, stop := s.pull(rec)
+	defer stop()
+	if sample := reservoirSample(next, 1, randOf(s.ctx)); len(sample) == 1 {
+		return sample[0], true
+	}
+	return t, false
 }
-func (s *asyncStreamer[T]) Any() T { return s.Take() }
-func (s *asyncStreamer[T]) Last() T {
-	data := s.fetchAll()
-	return data[len(data)-1]
+func (s *asyncStreamer[T]) AnyOK() (T, bool) { return s.TakeOK() }
+func (s *asyncStreamer[T]) LastOK() (t T, ok bool) {
+	rec := s.begin()
+	if !s.finite(rec) {
+		return t, false
+	}
+	next, stop := s.pull(rec)
+	defer stop()
+	for item, more := next(); more; item, more = next() {
+		t, ok = item, true
+	}
+	return t, ok
+}
+
+func (s *asyncStreamer[T]) TopK(k int, comparator types.Comparator[T]) []T {
+	return topK[T](s, k, comparator)
 }
+func (s *asyncStreamer[T]) BottomK(k int, comparator types.Comparator[T]) []T {
+	return topK[T](s, k, comparator.Reversed())
+}
+func (s *asyncStreamer[T]) Sample(k int) []T {
+	rec := s.begin()
+	if !s.finite(rec) {
+		return nil
+	}
+	next, stop := s.pull(rec)
+	defer stop()
+	return reservoirSample(next, k, randOf(s.ctx))
+}
+func (s *asyncStreamer[T]) SampleWeighted(k int, weight func(T) float64) []T {
+	rec := s.begin()
+	if !s.finite(rec) {
+		return nil
+	}
+	next, stop := s.pull(rec)
+	defer stop()
+	return weightedSample(next, k, weight, randOf(s.ctx))
+}
+
+func (s *asyncStreamer[T]) Count() (count int64) {
+	rec := s.begin()
+	if !s.finite(rec) {
+		return 0
+	}
 
-func (s *asyncStreamer[T]) Count() int64 { return s.sync().Count() }
+	ch, stop := s.start(rec)
+	defer stop()
+	for batch := range ch {
+		if s.cancelled() {
+			return count
+		}
+		count += int64(len(batch))
+	}
+	return count
+}
+func (s *asyncStreamer[T]) EncodeJSONLines(w io.Writer) error {
+	rec := s.begin()
+	if !s.finite(rec) {
+		return rec.Err()
+	}
+	next, stop := s.pull(rec)
+	defer stop()
+	if err := encodeJSONLines(next, w); err != nil {
+		return err
+	}
+	return rec.Err()
+}
+func (s *asyncStreamer[T]) Err() error {
+	if rec := s.last.Load(); rec != nil {
+		return rec.Err()
+	}
+	return recorderOf(s.ctx).Err()
+}
 
 func (s *asyncStreamer[T]) sync() Streamer[T] {
-	return wrapStreamer(newIterator[T](nil), func(iter iterator[T]) iterator[T] { return iter.Concat(newIterator(s.fetchAll())) }).WithContext(s.ctx)
+	return wrapStreamer(newIterator[T](nil), func(rec *errRecorder, iter iterator[T]) iterator[T] {
+		return iter.Concat(newIterator(s.fetchAll(rec)))
+	}).WithContext(s.ctx)
 }
-func (s *asyncStreamer[T]) fetchAll() (source []T) {
-	for t := range s.stage() {
+func (s *asyncStreamer[T]) fetchAll(rec *errRecorder) (source []T) {
+	if !s.finite(rec) {
+		return nil
+	}
+
+	ch, stop := s.start(rec)
+	defer stop()
+	for batch := range ch {
 		if s.cancelled() {
 			return source
 		}
-		source = append(source, t)
+		source = append(source, batch...)
 	}
 	return source
 }
 
-func (s *asyncStreamer[T]) wrapAsyncStage(work func(T, chan<- T)) asyncStage[T] {
-	return func() <-chan T {
-		ch := make(chan T, 1024)
-		go func(size int) {
-			defer close(ch)
-			pool := pools.NewPool(size)
-			for t := range s.stage() {
-				pool.Wait()
-				go func(t T) {
-					defer pool.Done()
-					work(t, ch)
-				}(t)
+func (s *asyncStreamer[T]) wrapAsyncStage(work func(T) (T, bool)) asyncStage[T] {
+	return func(ctx context.Context, batchSize int) <-chan []T {
+		return runWorkers(ctx, s.stage(ctx, batchSize), s.asyncOptions, work)
+	}
+}
+
+// runWorkers start opts.parallelSize long-lived workers pulling batches from in,
+// work return result and whether to send it downstream, results of one batch are sent as one batch.
+// When executor is set, workers hand batches over to it and send results themselves,
+// so tasks never block on downstream while holding executor capacity.
+// Workers exit without draining in once ctx done.
+func runWorkers[T, R any](ctx context.Context, in <-chan []T, opts asyncOptions, work func(T) (R, bool)) <-chan []R {
+	out := make(chan []R, bufferOf(opts.batchSize))
+
+	workers := opts.parallelSize
+	if workers <= 0 {
+		workers = 1
+	}
+
+	// batch is owned by worker once received, results of the same type overwrite handled elements
+	reuse, _ := any(func(items []T) []T { return items[:0] }).(func([]T) []R)
+	process := func(items []T) (results []R) {
+		if reuse != nil {
+			results = reuse(items)
+		} else {
+			results = make([]R, 0, len(items))
+		}
+		for _, t := range items {
+			if r, ok := work(t); ok {
+				results = append(results, r)
+			}
+		}
+		return results
+	}
+	run := process
+	if opts.executor != nil {
+		run = func(items []T) (results []R) {
+			done := make(chan struct{})
+			opts.executor.Go(func() {
+				defer close(done)
+				results = process(items)
+			})
+			<-done
+			return results
+		}
+	}
+
+	var wg sync.WaitGroup
+	wg.Add(workers)
+	for i := 0; i < workers; i++ {
+		go func() {
+			defer wg.Done()
+
+			for items := range in {
+				if results := run(items); len(results) > 0 && !send(ctx, out, results) {
+					return
+				}
+			}
+		}()
+	}
+	go func() {
+		wg.Wait()
+		close(out)
+	}()
+	return out
+}
+
+// batchOf group elements from in into batches of size until in closed or ctx done
+func batchOf[T any](ctx context.Context, in <-chan T, size int) <-chan []T {
+	out := make(chan []T, bufferOf(size))
+	go func() {
+		defer close(out)
+
+		next := func() (t T, ok bool) {
+			select {
+			case t, ok = <-in:
+				return t, ok
+			case <-ctx.Done():
+				return t, false
+			}
+		}
+		sendBatches(ctx, out, next, size)
+	}()
+	return out
+}
+
+// sendBatches send elements pulled from next to out in batches of size, report false if ctx done before all sent.
+// A batch is sent once it is full or next ends, 0 or 1 size sends elements one by one.
+func sendBatches[T any](ctx context.Context, out chan<- []T, next func() (T, bool), size int) bool {
+	if size <= 0 {
+		size = 1
+	}
+
+	batch := make([]T, 0, size)
+	for t, ok := next(); ok; t, ok = next() {
+		if batch = append(batch, t); len(batch) == size {
+			if !send(ctx, out, batch) {
+				return false
 			}
-			pool.WaitAll()
-		}(s.parallelSize)
-		return ch
+			batch = make([]T, 0, size)
+		}
+	}
+	return len(batch) == 0 || send(ctx, out, batch)
+}
+
+// bufferOf return channel buffer size holding about 1024 elements in batches of size
+func bufferOf(size int) int {
+	if size <= 1 {
+		return 1024
+	}
+	if buffer := 1024 / size; buffer > 0 {
+		return buffer
+	}
+	return 1
+}
+
+// send send t to ch, report false if ctx done before t sent
+func send[T any](ctx context.Context, ch chan<- T, t T) bool {
+	select {
+	case ch <- t:
+		return true
+	case <-ctx.Done():
+		return false
 	}
 }
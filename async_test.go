@@ -0,0 +1,142 @@
+package stream
+
+import (
+	"context"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/pkg/pools"
+)
+
+const benchSize = 100000
+
+func benchSource() []int {
+	data := make([]int, benchSize)
+	for i := range data {
+		data[i] = i
+	}
+	return data
+}
+
+func cheapMapper(i int) int { return i*31 + 7 }
+
+// goroutinePerElement is the former async stage: one goroutine per element throttled by a pool
+func goroutinePerElement[T any](in <-chan T, size int, work func(T, chan<- T)) <-chan T {
+	ch := make(chan T, 1024)
+	go func() {
+		defer close(ch)
+		pool := pools.NewPool(size)
+		for t := range in {
+			pool.Wait()
+			go func(t T) {
+				defer pool.Done()
+				work(t, ch)
+			}(t)
+		}
+		pool.WaitAll()
+	}()
+	return ch
+}
+
+func feed[T any](data []T) <-chan T {
+	ch := make(chan T, 1024)
+	go func() {
+		defer close(ch)
+		for _, t := range data {
+			ch <- t
+		}
+	}()
+	return ch
+}
+
+func TestRunWorkers(t *testing.T) {
+	for _, opts := range []asyncOptions{
+		{parallelSize: 4},
+		{parallelSize: 4, batchSize: 64},
+		{parallelSize: 4, batchSize: 7, executor: NewPoolExecutor(2)},
+		{parallelSize: 2, executor: InlineExecutor{}},
+	} {
+		var sum, count int
+		in := batchOf(context.Background(), feed(benchSource()[:1000]), opts.batchSize)
+		for batch := range runWorkers(context.Background(), in, opts, func(i int) (int, bool) { return i, i%2 == 0 }) {
+			if opts.batchSize > 1 && len(batch) > opts.batchSize {
+				t.Errorf("options %+v: expect batch no larger than %d, got %d", opts, opts.batchSize, len(batch))
+			}
+			for _, i := range batch {
+				sum += i
+				count++
+			}
+		}
+		if count != 500 || sum != 249500 {
+			t.Errorf("options %+v: expect 500 elements sum 249500, got %d elements sum %d", opts, count, sum)
+		}
+	}
+}
+
+func TestEarlyStop(t *testing.T) {
+	data := benchSource()
+	base := runtime.NumGoroutine()
+	for _, opts := range []asyncOptions{
+		{parallelSize: 4},
+		{parallelSize: 4, batchSize: 16},
+		{parallelSize: 4, executor: NewPoolExecutor(2)},
+	} {
+		s := func() Streamer[int] {
+			return SliceOf(data...).Parallel(opts.parallelSize).WithBatchSize(opts.batchSize).WithExecutor(opts.executor).Map(cheapMapper)
+		}
+		if _, ok := s().FirstOK(); !ok {
+			t.Errorf("options %+v: expect FirstOK", opts)
+		}
+		if count := s().Limit(3).Count(); count != 3 {
+			t.Errorf("options %+v: expect Limit 3, got %d", opts, count)
+		}
+		if !s().AnyMatch(func(int) bool { return true }) {
+			t.Errorf("options %+v: expect AnyMatch", opts)
+		}
+	}
+
+	// workers blocked on sending results must exit once consumer stops
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > base; time.Sleep(10 * time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect %d goroutines after early stop, got %d", base, runtime.NumGoroutine())
+		}
+	}
+}
+
+func BenchmarkMapSequential(b *testing.B) {
+	data := benchSource()
+	for i := 0; i < b.N; i++ {
+		SliceOf(data...).Map(cheapMapper).ToSlice()
+	}
+}
+
+func BenchmarkMapGoroutinePerElement(b *testing.B) {
+	data := benchSource()
+	for i := 0; i < b.N; i++ {
+		for range goroutinePerElement(feed(data), 8, func(t int, ch chan<- int) { ch <- cheapMapper(t) }) {
+		}
+	}
+}
+
+func BenchmarkMapWorkers(b *testing.B) {
+	data := benchSource()
+	for i := 0; i < b.N; i++ {
+		SliceOf(data...).Parallel(8).Map(cheapMapper).ToSlice()
+	}
+}
+
+func BenchmarkMapWorkersBatch(b *testing.B) {
+	data := benchSource()
+	for i := 0; i < b.N; i++ {
+		SliceOf(data...).Parallel(8).WithBatchSize(256).Map(cheapMapper).ToSlice()
+	}
+}
+
+func BenchmarkMapWorkersExecutor(b *testing.B) {
+	data := benchSource()
+	executor := NewPoolExecutor(8)
+	for i := 0; i < b.N; i++ {
+		SliceOf(data...).Parallel(8).WithExecutor(executor).WithBatchSize(256).Map(cheapMapper).ToSlice()
+	}
+}
@@ -0,0 +1,52 @@
+package stream
+
+import (
+	"math"
+	"sync"
+)
+
+// bloomFilter bloom filter for approximate membership, safe for concurrent use
+type bloomFilter struct {
+	mu   sync.Mutex
+	bits []uint64
+	m    uint64 // bit number
+	k    uint64 // hash function number
+}
+
+// newBloomFilter return bloom filter sized for expectedItems with false positive rate fpRate
+func newBloomFilter(expectedItems int, fpRate float64) *bloomFilter {
+	if expectedItems <= 0 {
+		expectedItems = 1
+	}
+	if fpRate <= 0 || fpRate >= 1 {
+		fpRate = 0.01
+	}
+
+	m := uint64(math.Ceil(-float64(expectedItems) * math.Log(fpRate) / (math.Ln2 * math.Ln2)))
+	if m < 64 {
+		m = 64
+	}
+	k := uint64(math.Round(float64(m) / float64(expectedItems) * math.Ln2))
+	if k < 1 {
+		k = 1
+	}
+	return &bloomFilter{bits: make([]uint64, (m+63)/64), m: m, k: k}
+}
+
+// add add hash to filter, return false if hash may already exist
+func (f *bloomFilter) add(hash uint64) bool {
+	h1, h2 := hash, mix64(hash)|1 // double hashing
+
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	exists := true
+	for i := uint64(0); i < f.k; i++ {
+		bit := (h1 + i*h2) % f.m
+		if word, mask := bit/64, uint64(1)<<(bit%64); f.bits[word]&mask == 0 {
+			exists = false
+			f.bits[word] |= mask
+		}
+	}
+	return !exists
+}
@@ -0,0 +1,88 @@
+package stream
+
+import (
+	"context"
+	"math/rand"
+	"sync"
+	"sync/atomic"
+)
+
+// stream values carried by context, shared by all stages derived from the same stream
+type (
+	recorderKey    struct{}
+	runRecorderKey struct{}
+	randKey        struct{}
+)
+
+// withRecorder return ctx carrying a new error recorder of stream sources
+func withRecorder(ctx context.Context) context.Context {
+	return context.WithValue(ctx, recorderKey{}, new(errRecorder))
+}
+
+// recorderOf return error recorder of stream sources in ctx, nil if not found
+func recorderOf(ctx context.Context) *errRecorder {
+	r, _ := ctx.Value(recorderKey{}).(*errRecorder)
+	return r
+}
+
+// newRunRecorder return recorder of one run, which also reports errors of stream sources in ctx
+func newRunRecorder(ctx context.Context) *errRecorder {
+	return &errRecorder{sources: recorderOf(ctx)}
+}
+
+// withRunRecorder return ctx carrying recorder of the run stages are started for
+func withRunRecorder(ctx context.Context, rec *errRecorder) context.Context {
+	return context.WithValue(ctx, runRecorderKey{}, rec)
+}
+
+// runRecorderOf return recorder of the run in ctx, nil if not found
+func runRecorderOf(ctx context.Context) *errRecorder {
+	r, _ := ctx.Value(runRecorderKey{}).(*errRecorder)
+	return r
+}
+
+// lastRun holds recorder of the latest run of a stream
+type lastRun = atomic.Pointer[errRecorder]
+
+// withRand return ctx carrying random generator using src
+func withRand(ctx context.Context, src rand.Source) context.Context {
+	return context.WithValue(ctx, randKey{}, rand.New(&lockedSource{src: src}))
+}
+
+// randOf return random generator in ctx, seededRand if not found
+func randOf(ctx context.Context) *rand.Rand {
+	if r, ok := ctx.Value(randKey{}).(*rand.Rand); ok {
+		return r
+	}
+	return seededRand
+}
+
+// inheritValues return ctx carrying stream values from parent which ctx has not
+func inheritValues(ctx, parent context.Context) context.Context {
+	for _, key := range []any{recorderKey{}, randKey{}} {
+		if ctx.Value(key) == nil {
+			if value := parent.Value(key); value != nil {
+				ctx = context.WithValue(ctx, key, value)
+			}
+		}
+	}
+	return ctx
+}
+
+// lockedSource rand.Source safe for concurrent use
+type lockedSource struct {
+	mu  sync.Mutex
+	src rand.Source
+}
+
+func (s *lockedSource) Int63() int64 {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.src.Int63()
+}
+
+func (s *lockedSource) Seed(seed int64) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.src.Seed(seed)
+}
@@ -0,0 +1,191 @@
+package stream
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"time"
+)
+
+// CSVOptions options for ReadCSV and WriteCSV
+type CSVOptions struct {
+	// Comma field delimiter, default ','
+	Comma rune
+	// TimeLayout layout of time.Time fields, default time.RFC3339
+	TimeLayout string
+
+	// Policy decide what to do with malformed rows, default ErrorFail
+	Policy ErrorPolicy
+	// DeadLetter receive malformed rows under ErrorDeadLetter policy
+	DeadLetter func(*LineError)
+}
+
+func (o CSVOptions) withDefault() CSVOptions {
+	if o.Comma == 0 {
+		o.Comma = ','
+	}
+	if o.TimeLayout == "" {
+		o.TimeLayout = time.RFC3339
+	}
+	return o
+}
+
+// ReadCSV create a new stream decoding rows of r to struct T or *T lazily.
+// The first row is header, columns are mapped to fields by `csv:"name"` tag or field name,
+// `csv:"-"` fields are ignored and untagged nested structs are flattened.
+// Empty cells leave pointer fields nil, malformed rows are handled by opts.Policy.
+func ReadCSV[T any](r io.Reader, opts ...CSVOptions) Streamer[T] {
+	var opt CSVOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt = opt.withDefault()
+	policy := DecodeOptions{Policy: opt.Policy, DeadLetter: opt.DeadLetter}
+
+	s := newStreamer[T](nil)
+	rec := recorderOf(s.ctx)
+	typ, fields, err := fieldsOf[T]("csv")
+	if err != nil {
+		rec.record(err)
+		s.source = newIterator[T](nil)
+		return s
+	}
+
+	var cr *csv.Reader
+	var closer io.Closer
+	var columns []*textField
+	var done bool
+	stop := func(err error) {
+		if done = true; err != nil {
+			rec.record(err)
+		}
+		if closer != nil {
+			_ = closer.Close()
+		}
+	}
+	s.source = newSupplyIter(func() (t T, ok bool) {
+		if done {
+			return t, false
+		}
+		if cr == nil { // open reader and read header lazily
+			in, c, err := decompress(r)
+			if closer = c; err == nil {
+				cr = csv.NewReader(in)
+				cr.Comma, cr.ReuseRecord = opt.Comma, true
+				columns, err = csvColumns(cr, fields)
+			}
+			if err != nil {
+				stop(fmt.Errorf("read csv header: %w", err))
+				return t, false
+			}
+		}
+
+		for {
+			record, err := cr.Read()
+			if err == io.EOF {
+				stop(nil)
+				return t, false
+			}
+
+			var line int
+			var parseErr *csv.ParseError
+			if errors.As(err, &parseErr) {
+				line = parseErr.Line
+			} else if err != nil {
+				stop(fmt.Errorf("read csv: %w", err))
+				return t, false
+			} else {
+				line, _ = cr.FieldPos(0)
+			}
+			if err == nil {
+				value := reflect.New(typ).Elem()
+				if err = decodeCSVRow(value, columns, record, opt.TimeLayout); err == nil {
+					return structElem[T](value), true
+				}
+			}
+			if policy.reject(rec, &LineError{Line: line, Text: strings.Join(record, string(opt.Comma)), Err: err}) {
+				stop(nil)
+				return t, false
+			}
+		}
+	}, sizeUnknown)
+	return s
+}
+
+// WriteCSV write elements of struct T or *T to w as CSV in stream order, with a header row built from the same tags as ReadCSV.
+// Nil pointers are written as empty cells.
+func WriteCSV[T any](s Streamer[T], w io.Writer, opts ...CSVOptions) error {
+	var opt CSVOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt = opt.withDefault()
+
+	_, fields, err := fieldsOf[T]("csv")
+	if err != nil {
+		return err
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Comma = opt.Comma
+	record := make([]string, len(fields))
+	for i, f := range fields {
+		record[i] = f.name
+	}
+	if err = cw.Write(record); err != nil {
+		return err
+	}
+
+	next, stop := pullAll(s)
+	defer stop()
+	for t, ok := next(); ok; t, ok = next() {
+		value := reflect.ValueOf(&t).Elem()
+		if value.Kind() == reflect.Pointer {
+			value = value.Elem()
+		}
+		for i, f := range fields {
+			if record[i], err = f.format(value, opt.TimeLayout); err != nil {
+				return fmt.Errorf("write csv field %s: %w", f.name, err)
+			}
+		}
+		if err = cw.Write(record); err != nil {
+			return err
+		}
+	}
+	if cw.Flush(); cw.Error() != nil {
+		return cw.Error()
+	}
+	return s.Err()
+}
+
+// csvColumns read header and return field of each column, nil for unmapped columns
+func csvColumns(cr *csv.Reader, fields []*textField) ([]*textField, error) {
+	header, err := cr.Read()
+	if err != nil {
+		return nil, err
+	}
+	byName := make(map[string]*textField, len(fields))
+	for _, f := range fields {
+		byName[f.name] = f
+	}
+	columns := make([]*textField, len(header))
+	for i, name := range header {
+		columns[i] = byName[strings.TrimSpace(name)]
+	}
+	return columns, nil
+}
+
+func decodeCSVRow(value reflect.Value, columns []*textField, record []string, layout string) error {
+	for i, cell := range record {
+		if i >= len(columns) || columns[i] == nil {
+			continue
+		}
+		if err := columns[i].parse(value, cell, layout); err != nil {
+			return fmt.Errorf("column %s: %w", columns[i].name, err)
+		}
+	}
+	return nil
+}
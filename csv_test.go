@@ -0,0 +1,71 @@
+package stream_test
+
+import (
+	"bytes"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/stream"
+	"github.com/tr1v3r/stream/tests"
+)
+
+type account struct {
+	ID      int64     `csv:"id"`
+	Name    string    `csv:"name"`
+	Score   *float64  `csv:"score"`
+	Active  bool      `csv:"active"`
+	Joined  time.Time `csv:"joined"`
+	Comment string    `csv:"-"`
+}
+
+func TestReadCSV(t *testing.T) {
+	data := "name,id,score,active,joined,extra\n" +
+		"alice,1,9.5,true,2024-01-02,x\n" +
+		"bob,two,1,false,2024-01-03,x\n" +
+		"carol,3,,false,2024-01-04,x\n"
+	opts := stream.CSVOptions{TimeLayout: "2006-01-02"}
+
+	s := stream.ReadCSV[account](strings.NewReader(data), opts)
+	result := s.ToSlice()
+	if len(result) != 1 || result[0].Name != "alice" || *result[0].Score != 9.5 || !result[0].Active || result[0].Joined.Day() != 2 {
+		t.Errorf("fail: expect alice only, got %+v", result)
+	}
+	var lineErr *stream.LineError
+	if err := s.Err(); !errors.As(err, &lineErr) || lineErr.Line != 3 {
+		t.Errorf("fail: expect LineError of line 3, got %v", err)
+	}
+
+	opts.Policy = stream.ErrorSkip
+	accounts := stream.ReadCSV[*account](strings.NewReader(data), opts).ToSlice()
+	if len(accounts) != 2 || accounts[1].ID != 3 || accounts[1].Score != nil {
+		t.Errorf("skip: expect alice and carol with nil score, got %+v", accounts)
+	}
+
+	if err := stream.ReadCSV[int](strings.NewReader(data)).Err(); !errors.Is(err, stream.ErrUnsupportType) {
+		t.Errorf("expect ErrUnsupportType, got %v", err)
+	}
+}
+
+func TestCSVRoundTrip(t *testing.T) {
+	name, city := "alice", "paris"
+	employees := []*tests.Employee{
+		{ID: 1, Name: &name, Age: 30, Phone: "123", Position: &tests.PositionInfo{City: &city}},
+		{ID: 2, Age: 40},
+	}
+
+	var buf bytes.Buffer
+	if err := stream.WriteCSV(stream.SliceOf(employees...), &buf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if header := strings.SplitN(buf.String(), "\n", 2)[0]; header != "ID,Name,Age,Phone,Province,Country,City" {
+		t.Errorf("unexpected header %q", header)
+	}
+
+	result := stream.ReadCSV[*tests.Employee](&buf).ToSlice()
+	if !reflect.DeepEqual(result, employees) {
+		t.Errorf("expect round trip, got %+v %+v", result[0], result[1])
+	}
+}
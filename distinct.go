@@ -0,0 +1,288 @@
+package stream
+
+import (
+	"container/list"
+	"fmt"
+	"reflect"
+	"sync"
+	"time"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// DistinctBy return streamer keeping the first element of each key extracted by key
+func DistinctBy[T any, K comparable](s Streamer[T], key func(T) K) Streamer[T] {
+	return filterWith(s, func(workers int) types.Judge[T] {
+		keys := newKeySet[K](workers)
+		return func(t T) bool { return keys.add(key(t)) }
+	})
+}
+
+// filterWith filter s by Judge built for each run with number of workers calling it, for judges holding state
+func filterWith[T any](s Streamer[T], newJudge func(workers int) types.Judge[T]) Streamer[T] {
+	switch s := s.(type) {
+	case *streamer[T]:
+		return s.filterWith(func() types.Judge[T] { return newJudge(1) })
+	case *asyncStreamer[T]:
+		return s.filterWith(func() types.Judge[T] { return newJudge(s.parallelSize) })
+	default:
+		return s.Filter(newJudge(1))
+	}
+}
+
+// distinctJudge return distinct judge called by workers concurrently
+func distinctJudge[T any](workers int) types.Judge[T] {
+	if judge, ok := basicJudge[T](workers); ok {
+		return judge
+	}
+
+	var (
+		keyOf = keyFunc[T]()
+		keys  = newKeySet[any](workers)
+	)
+	return func(t T) bool { return keys.add(keyOf(t)) }
+}
+
+// basicJudge return distinct judge keeping elements of basic type in typed set, so that elements are not boxed
+func basicJudge[T any](workers int) (types.Judge[T], bool) {
+	var judge any
+	switch any(*new(T)).(type) {
+	case string:
+		judge = setJudge[string](workers)
+	case bool:
+		judge = setJudge[bool](workers)
+	case int:
+		judge = setJudge[int](workers)
+	case int8:
+		judge = setJudge[int8](workers)
+	case int16:
+		judge = setJudge[int16](workers)
+	case int32:
+		judge = setJudge[int32](workers)
+	case int64:
+		judge = setJudge[int64](workers)
+	case uint:
+		judge = setJudge[uint](workers)
+	case uint8:
+		judge = setJudge[uint8](workers)
+	case uint16:
+		judge = setJudge[uint16](workers)
+	case uint32:
+		judge = setJudge[uint32](workers)
+	case uint64:
+		judge = setJudge[uint64](workers)
+	case float32:
+		judge = setJudge[float32](workers)
+	case float64:
+		judge = setJudge[float64](workers)
+	}
+	j, ok := judge.(types.Judge[T])
+	return j, ok
+}
+
+func setJudge[K comparable](workers int) types.Judge[K] {
+	keys := newKeySet[K](workers)
+	return func(k K) bool { return keys.add(k) }
+}
+
+// maxKeyShards upper bound of keySet shards
+const maxKeyShards = 64
+
+// keySet set of seen keys, safe for concurrent use by parallel workers,
+// keys are sharded by hash so that workers seldom contend on the same lock
+type keySet[K comparable] struct {
+	shards []keyShard[K]
+	mask   uint64
+}
+
+type keyShard[K comparable] struct {
+	mu   sync.Mutex
+	keys map[K]struct{}
+}
+
+// newKeySet return key set used by workers, a single worker uses one shard without hashing keys
+func newKeySet[K comparable](workers int) *keySet[K] {
+	shards := 1
+	for workers > 1 && shards < 4*workers && shards < maxKeyShards {
+		shards <<= 1
+	}
+	return &keySet[K]{shards: make([]keyShard[K], shards), mask: uint64(shards - 1)}
+}
+
+// add add key to set, return false if key already exists
+func (s *keySet[K]) add(key K) bool {
+	shard := &s.shards[0]
+	if s.mask > 0 {
+		shard = &s.shards[shardHash(key)&s.mask]
+	}
+
+	shard.mu.Lock()
+	defer shard.mu.Unlock()
+
+	if _, ok := shard.keys[key]; ok {
+		return false
+	}
+	if shard.keys == nil {
+		shard.keys = make(map[K]struct{})
+	}
+	shard.keys[key] = struct{}{}
+	return true
+}
+
+func distinctWithinJudge[T any](n int) types.Judge[T] {
+	var (
+		keyOf = keyFunc[T]()
+		keys  = newLRUKeySet[any](n)
+	)
+	return func(t T) bool { return keys.add(keyOf(t)) }
+}
+
+// DistinctTTLOptions options for DistinctTTL
+type DistinctTTLOptions struct {
+	// Clock time source of key expiry, default system clock
+	Clock Clock
+}
+
+func (o DistinctTTLOptions) withDefault() DistinctTTLOptions {
+	if o.Clock == nil {
+		o.Clock = systemClock{}
+	}
+	return o
+}
+
+func distinctTTLJudge[T any](ttl time.Duration, opts ...DistinctTTLOptions) types.Judge[T] {
+	var opt DistinctTTLOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt = opt.withDefault()
+
+	var (
+		keyOf = keyFunc[T]()
+		keys  = newTTLKeySet[any](ttl)
+	)
+	return func(t T) bool { return keys.add(keyOf(t), opt.Clock.Now()) }
+}
+
+func distinctApproxJudge[T any](expectedItems int, fpRate float64) types.Judge[T] {
+	var (
+		keyOf  = keyFunc[T]()
+		filter = newBloomFilter(expectedItems, fpRate)
+	)
+	return func(t T) bool { return filter.add(hashKey(keyOf(t))) }
+}
+
+// lruKeySet remember the last size keys, safe for concurrent use by parallel workers
+type lruKeySet[K comparable] struct {
+	mu    sync.Mutex
+	size  int
+	order *list.List // front is the most recently seen key
+	keys  map[K]*list.Element
+}
+
+func newLRUKeySet[K comparable](size int) *lruKeySet[K] {
+	if size <= 0 {
+		size = 1
+	}
+	return &lruKeySet[K]{size: size, order: list.New(), keys: make(map[K]*list.Element, size)}
+}
+
+// add add key to set, return false if key is remembered
+func (s *lruKeySet[K]) add(key K) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if elem, ok := s.keys[key]; ok {
+		s.order.MoveToFront(elem)
+		return false
+	}
+	s.keys[key] = s.order.PushFront(key)
+	if s.order.Len() > s.size {
+		delete(s.keys, s.order.Remove(s.order.Back()).(K))
+	}
+	return true
+}
+
+// ttlKeySet remember keys for ttl since they are first seen, safe for concurrent use by parallel workers
+type ttlKeySet[K comparable] struct {
+	mu    sync.Mutex
+	ttl   time.Duration
+	order *list.List // keys in order of expiry
+	keys  map[K]time.Time
+}
+
+type ttlKey[K comparable] struct {
+	key    K
+	expire time.Time
+}
+
+func newTTLKeySet[K comparable](ttl time.Duration) *ttlKeySet[K] {
+	return &ttlKeySet[K]{ttl: ttl, order: list.New(), keys: make(map[K]time.Time)}
+}
+
+// add add key seen at now to set, return false if key is remembered
+func (s *ttlKeySet[K]) add(key K, now time.Time) bool {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for front := s.order.Front(); front != nil && !front.Value.(ttlKey[K]).expire.After(now); front = s.order.Front() {
+		delete(s.keys, s.order.Remove(front).(ttlKey[K]).key)
+	}
+
+	if _, ok := s.keys[key]; ok {
+		return false
+	}
+	expire := now.Add(s.ttl)
+	s.keys[key] = expire
+	s.order.PushBack(ttlKey[K]{key: key, expire: expire})
+	return true
+}
+
+// sprintKey formatted key of non-comparable element, never equal to a string element
+type sprintKey string
+
+// keyFunc return function building map key for element:
+// Key() for types.Unique, element itself for comparable type, or formatted sprintKey as fallback
+func keyFunc[T any]() func(T) any {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	switch {
+	case typ.Implements(reflect.TypeOf((*types.Unique)(nil)).Elem()):
+		return func(t T) any { return any(t).(types.Unique).Key() }
+	case typ.Kind() != reflect.Interface && hashable(typ):
+		return func(t T) any { return t }
+	default: // decide by dynamic type
+		return func(t T) any {
+			switch v := any(t).(type) {
+			case nil:
+				return nil
+			case types.Unique:
+				return v.Key()
+			default:
+				if reflect.ValueOf(v).Comparable() {
+					return v
+				}
+				return sprintKey(fmt.Sprint(v))
+			}
+		}
+	}
+}
+
+// hashable report whether all values of typ can be used as map key without panic,
+// comparable types holding interfaces are not hashable as dynamic values may not be comparable
+func hashable(typ reflect.Type) bool {
+	switch typ.Kind() {
+	case reflect.Interface:
+		return false
+	case reflect.Array:
+		return hashable(typ.Elem())
+	case reflect.Struct:
+		for i := 0; i < typ.NumField(); i++ {
+			if !hashable(typ.Field(i).Type) {
+				return false
+			}
+		}
+		return true
+	default:
+		return typ.Comparable()
+	}
+}
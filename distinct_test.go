@@ -0,0 +1,134 @@
+package stream_test
+
+import (
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/stream"
+)
+
+type user struct {
+	ID   int
+	Name string
+}
+
+type caseless string
+
+func (c caseless) Key() string { return strings.ToLower(string(c)) }
+
+func TestDistinct(t *testing.T) {
+	if result := stream.SliceOf(4, 1, 3, 3, 2, 4).Distinct().ToSlice(); !reflect.DeepEqual(result, []int{4, 1, 3, 2}) {
+		t.Errorf("expect [4 1 3 2], got %v", result)
+	}
+
+	a, b := &user{1, "a"}, &user{1, "a"}
+	if result := stream.SliceOf(a, b, a).Distinct().ToSlice(); len(result) != 2 {
+		t.Errorf("expect distinct pointers kept, got %d elements", len(result))
+	}
+
+	if result := stream.SliceOf[caseless]("Go", "go", "GO", "Rust").Distinct().ToSlice(); !reflect.DeepEqual(result, []caseless{"Go", "Rust"}) {
+		t.Errorf("expect [Go Rust], got %v", result)
+	}
+
+	if result := stream.SliceOf[any]([]int{1}, []int{1}, 1, "1", 1).Distinct().ToSlice(); len(result) != 3 {
+		t.Errorf("expect 3 elements, got %v", result)
+	}
+	if result := stream.SliceOf[any]([]int{1}, "[1]", []int{1}).Distinct().ToSlice(); len(result) != 2 {
+		t.Errorf("expect non-comparable element kept apart from string of its text, got %v", result)
+	}
+}
+
+func TestDistinctAllocs(t *testing.T) {
+	ints, words := make([]int, 1000), make([]string, 1000)
+	for i := range ints {
+		ints[i], words[i] = 1000+i%100, strconv.Itoa(1000+i%100)
+	}
+
+	// keys of basic type are kept unboxed, allocations come from the result and the set only
+	if allocs := testing.AllocsPerRun(10, func() { stream.SliceOf(ints...).Distinct().ToSlice() }); allocs > 100 {
+		t.Errorf("Distinct ints: expect allocations not growing with elements, got %.0f", allocs)
+	}
+	if allocs := testing.AllocsPerRun(10, func() { stream.SliceOf(words...).Distinct().ToSlice() }); allocs > 100 {
+		t.Errorf("Distinct strings: expect allocations not growing with elements, got %.0f", allocs)
+	}
+}
+
+func TestDistinctBy(t *testing.T) {
+	users := []*user{{1, "a"}, {2, "b"}, {1, "c"}, {3, "b"}}
+
+	var names []string
+	for _, u := range stream.DistinctBy(stream.SliceOf(users...), func(u *user) int { return u.ID }).ToSlice() {
+		names = append(names, u.Name)
+	}
+	if !reflect.DeepEqual(names, []string{"a", "b", "b"}) {
+		t.Errorf("expect [a b b], got %v", names)
+	}
+}
+
+// run with -race to detect data race of stateful operators under Parallel
+func TestDistinctParallel(t *testing.T) {
+	data := make([]int, 10000)
+	for i := range data {
+		data[i] = i % 100
+	}
+
+	if count := len(stream.SliceOf(data...).Parallel(8).Distinct().ToSlice()); count != 100 {
+		t.Errorf("Distinct: expect 100 elements, got %d", count)
+	}
+	if count := len(stream.DistinctBy(stream.SliceOf(data...).Parallel(8), func(i int) int { return i % 10 }).ToSlice()); count != 10 {
+		t.Errorf("DistinctBy: expect 10 elements, got %d", count)
+	}
+	if count := len(stream.SliceOf(data...).Parallel(8).DistinctWithin(100).ToSlice()); count < 100 {
+		t.Errorf("DistinctWithin: expect at least 100 elements, got %d", count)
+	}
+	if count := len(stream.SliceOf(data...).Parallel(8).DistinctTTL(time.Hour).ToSlice()); count != 100 {
+		t.Errorf("DistinctTTL: expect 100 elements, got %d", count)
+	}
+	if count := len(stream.SliceOf(data...).Parallel(8).DistinctApprox(100, 0.01).ToSlice()); count > 100 || count < 90 {
+		t.Errorf("DistinctApprox: expect about 100 elements, got %d", count)
+	}
+}
+
+// manualClock Clock moved forward by test only
+type manualClock struct{ now time.Time }
+
+func (c *manualClock) Now() time.Time { return c.now }
+
+func TestDistinctBounded(t *testing.T) {
+	data := []int{1, 2, 1, 3, 4, 1, 4}
+	if result := stream.SliceOf(data...).DistinctWithin(2).ToSlice(); !reflect.DeepEqual(result, []int{1, 2, 3, 4, 1}) {
+		t.Errorf("DistinctWithin: expect [1 2 3 4 1], got %v", result)
+	}
+
+	var i int
+	clock := &manualClock{}
+	result := stream.Of(func() (int, bool) {
+		if i++; i == 3 {
+			clock.now = clock.now.Add(30 * time.Millisecond)
+		}
+		return 7, i <= 4
+	}).DistinctTTL(20*time.Millisecond, stream.DistinctTTLOptions{Clock: clock}).ToSlice()
+	if !reflect.DeepEqual(result, []int{7, 7}) {
+		t.Errorf("DistinctTTL: expect [7 7], got %v", result)
+	}
+
+	words := make([]string, 0, 2000)
+	for i := 0; i < 1000; i++ {
+		words = append(words, strconv.Itoa(i), strconv.Itoa(i))
+	}
+	if count := len(stream.SliceOf(words...).Parallel(4).DistinctApprox(1000, 0.01).ToSlice()); count > 1000 || count < 970 {
+		t.Errorf("DistinctApprox: expect about 1000 elements, got %d", count)
+	}
+
+	type point struct{ X, Y int }
+	p1, p2 := &point{1, 2}, &point{1, 2}
+	if result := stream.SliceOf(p1, p2, p1).DistinctApprox(100, 0.01).ToSlice(); len(result) != 2 || result[0] != p1 || result[1] != p2 {
+		t.Errorf("DistinctApprox: expect pointers distinct by address, got %v", result)
+	}
+	if result := stream.SliceOf[int8](-1, 1, -1, 2).DistinctApprox(100, 0.01).ToSlice(); !reflect.DeepEqual(result, []int8{-1, 1, 2}) {
+		t.Errorf("DistinctApprox: expect [-1 1 2], got %v", result)
+	}
+}
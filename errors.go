@@ -1,8 +1,94 @@
 package stream
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sync"
+)
 
 var (
 	// ErrUnsupportType unsupport type
 	ErrUnsupportType = errors.New("unsupport type")
+	// ErrInfiniteStream operator needs all elements of a known infinite stream
+	ErrInfiniteStream = errors.New("operator needs a finite stream")
+	// ErrSketchMismatch sketches with different parameters cannot be merged
+	ErrSketchMismatch = errors.New("sketch parameters mismatch")
+)
+
+// errRecorder record the first error met by stream sources and stages
+type errRecorder struct {
+	mu      sync.Mutex
+	err     error
+	sources *errRecorder // recorder of stream sources, reported if nothing recorded
+}
+
+func (r *errRecorder) record(err error) {
+	if r == nil || err == nil {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.err == nil {
+		r.err = err
+	}
+}
+
+func (r *errRecorder) Err() error {
+	if r == nil {
+		return nil
+	}
+	r.mu.Lock()
+	err := r.err
+	r.mu.Unlock()
+	if err == nil {
+		return r.sources.Err()
+	}
+	return err
+}
+
+// ErrorPolicy decide what decoding sources do with malformed input
+type ErrorPolicy int
+
+const (
+	// ErrorFail stop the stream and report the error by Err
+	ErrorFail ErrorPolicy = iota
+	// ErrorSkip drop malformed input silently
+	ErrorSkip
+	// ErrorDeadLetter drop malformed input and hand it over to dead letter callback
+	ErrorDeadLetter
 )
+
+// LineError error of one malformed input line
+type LineError struct {
+	Line int    // line number, start from 1
+	Text string // raw line
+	Err  error
+}
+
+func (e *LineError) Error() string { return fmt.Sprintf("line %d: %v", e.Line, e.Err) }
+func (e *LineError) Unwrap() error { return e.Err }
+
+// DecodeOptions options for decoding sources
+type DecodeOptions struct {
+	ScanOptions
+
+	// Policy decide what to do with malformed lines, default ErrorFail
+	Policy ErrorPolicy
+	// DeadLetter receive malformed lines under ErrorDeadLetter policy
+	DeadLetter func(*LineError)
+}
+
+// reject handle malformed line by policy, report true if stream should stop
+func (o DecodeOptions) reject(rec *errRecorder, err *LineError) (stop bool) {
+	switch o.Policy {
+	case ErrorSkip:
+	case ErrorDeadLetter:
+		if o.DeadLetter != nil {
+			o.DeadLetter(err)
+		}
+	default:
+		rec.record(err)
+		return true
+	}
+	return false
+}
@@ -0,0 +1,45 @@
+package stream
+
+import "github.com/tr1v3r/pkg/pools"
+
+var (
+	_ Executor = NewPoolExecutor(1)
+	_ Executor = UnboundedExecutor{}
+	_ Executor = InlineExecutor{}
+)
+
+// Executor run tasks for parallel stages
+// one Executor can be shared by many streams to cap concurrency across all pipelines
+type Executor interface {
+	// Go run task, it may block until executor is able to accept task
+	Go(task func())
+}
+
+// NewPoolExecutor return a bounded executor, at most size tasks run at the same time
+func NewPoolExecutor(size int) Executor {
+	if size <= 0 {
+		size = 1
+	}
+	return &poolExecutor{pool: pools.NewPool(size)}
+}
+
+// poolExecutor bounded executor
+type poolExecutor struct{ pool pools.Pool }
+
+func (e *poolExecutor) Go(task func()) {
+	e.pool.Wait()
+	go func() {
+		defer e.pool.Done()
+		task()
+	}()
+}
+
+// UnboundedExecutor run every task in a new goroutine
+type UnboundedExecutor struct{}
+
+func (UnboundedExecutor) Go(task func()) { go task() }
+
+// InlineExecutor run task in caller goroutine
+type InlineExecutor struct{}
+
+func (InlineExecutor) Go(task func()) { task() }
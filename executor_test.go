@@ -0,0 +1,71 @@
+package stream_test
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestSharedExecutor(t *testing.T) {
+	var running, peak int64
+	mapper := func(i int) int {
+		n := atomic.AddInt64(&running, 1)
+		for p := atomic.LoadInt64(&peak); n > p && !atomic.CompareAndSwapInt64(&peak, p, n); p = atomic.LoadInt64(&peak) {
+		}
+		time.Sleep(time.Millisecond)
+		atomic.AddInt64(&running, -1)
+		return i * 2
+	}
+
+	executor := stream.NewPoolExecutor(3)
+
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := stream.SliceOf(1, 2, 3, 4, 5, 6, 7, 8).Parallel(8).WithExecutor(executor).Map(mapper).ToSlice()
+			if len(result) != 8 {
+				t.Errorf("expect 8 elements, got %d", len(result))
+			}
+		}()
+	}
+	wg.Wait()
+
+	if peak > 3 {
+		t.Errorf("expect at most 3 concurrent tasks, got %d", peak)
+	}
+}
+
+func TestInlineExecutor(t *testing.T) {
+	sum := stream.SliceOf(1, 2, 3, 4).WithExecutor(stream.InlineExecutor{}).
+		Map(func(i int) int { return i * i }).
+		ReduceFrom(0, func(acc, i int) int { return acc + i })
+	if sum != 30 {
+		t.Errorf("expect 30, got %d", sum)
+	}
+}
+
+func TestSharedExecutorStages(t *testing.T) {
+	data := make([]int, 10000)
+	done := make(chan int)
+	go func() {
+		executor := stream.NewPoolExecutor(2)
+		done <- len(stream.SliceOf(data...).Parallel(2).WithExecutor(executor).
+			Map(func(i int) int { return i + 1 }).
+			Map(func(i int) int { return i * 2 }).
+			ToSlice())
+	}()
+
+	select {
+	case count := <-done:
+		if count != len(data) {
+			t.Errorf("expect %d elements, got %d", len(data), count)
+		}
+	case <-time.After(10 * time.Second):
+		t.Fatal("stages sharing one executor deadlocked")
+	}
+}
@@ -2,13 +2,25 @@ package stream
 
 import (
 	"context"
+	"io"
+	"math/rand"
+	"time"
 
 	"github.com/tr1v3r/stream/types"
 )
 
+// Streamer lazy stream of elements.
+//
+// Sources like Repeat are infinite, Filter, Map, Peek, Convert, Limit, Skip, Pick with non-negative end
+// and FirstOK work on them lazily, pulling one element for each element asked downstream.
+// Sort, Reverse, Pick to the end and terminal operates visiting all elements need a finite stream,
+// on a known infinite stream they stop immediately and record ErrInfiniteStream, which is reported by Err,
+// unless stream context is cancellable, then they consume elements until it is cancelled.
 type Streamer[T any] interface {
 	// WithContext set Streamer context
 	WithContext(context.Context) Streamer[T]
+	// WithRand set random source used by Take, Sample and SampleRate, for reproducible results
+	WithRand(rand.Source) Streamer[T]
 
 	// stateless operate 无状态操作
 
@@ -17,16 +29,31 @@ type Streamer[T any] interface {
 	Map(types.Mapper[T]) Streamer[T]
 	Convert(types.Converter[T, any]) Streamer[any]
 	Peek(types.Consumer[T]) Streamer[T]
+	// SampleRate keep each element with probability p
+	SampleRate(p float64) Streamer[T]
 	// FlatMap(func(T) Streamer[any]) Streamer[any]
 
 	// stateful operate 有状态操作
 
 	Distinct() Streamer[T]
+	// DistinctWithin distinct data by the last n keys, keys seen earlier are forgotten
+	DistinctWithin(n int) Streamer[T]
+	// DistinctTTL distinct data by keys seen in duration, keys expire after duration since first seen
+	DistinctTTL(time.Duration, ...DistinctTTLOptions) Streamer[T]
+	// DistinctApprox distinct data with a bloom filter in bounded memory,
+	// false positives may drop a few unseen elements but duplicates are never kept
+	DistinctApprox(expectedItems int, fpRate float64) Streamer[T]
 	Sort(types.Comparator[T]) Streamer[T]
+	// SortStable sort data keeping the original order of equal elements
+	SortStable(types.Comparator[T]) Streamer[T]
 	ReverseSort(types.Comparator[T]) Streamer[T]
+	// ExternalSort stable sort data larger than memory, sorted runs are spilled to temp files and merged lazily
+	ExternalSort(types.Comparator[T], ExternalSortOptions) Streamer[T]
 	Reverse() Streamer[T]
 	Limit(int64) Streamer[T]
 	Skip(int64) Streamer[T]
+	// Pick pick elements at startIndex, startIndex+interval, ... no greater than endIndex,
+	// negative endIndex means to the end, invalid range pick nothing
 	Pick(startIndex, endIndex, interval int) Streamer[T]
 
 	// Append append data to streamer source
@@ -36,6 +63,12 @@ type Streamer[T any] interface {
 
 	// Parallel 0 do nothing, 1 async work, 2-n concurrent work
 	Parallel(int) Streamer[T]
+	// WithExecutor run parallel work on executor instead of a new pool for each stage,
+	// nil executor restore the default behavior
+	WithExecutor(Executor) Streamer[T]
+	// WithBatchSize pass elements between parallel stages in micro-batches of size,
+	// a batch is handed over once full or source ends, so slow sources may delay elements
+	WithBatchSize(int) Streamer[T]
 
 	// terminal operate 终止操作
 
@@ -55,11 +88,31 @@ type Streamer[T any] interface {
 	// ReduceBy use `buildInitValue` to build the initValue, which parameter is a int64 means element size, or -1 if unknown size.
 	// Then use `accumulator` to add each element to previous result
 	ReduceBy(initValueBulider func(sizeMayNegative int) any, accumulator types.Accumulator[T, any]) any
-	// Pick one
+	// Pick one, return zero value if stream is empty
 	First() T
 	Take() T
 	Any() T
 	Last() T
+	// Pick one, report false if stream is empty
+	FirstOK() (T, bool)
+	TakeOK() (T, bool)
+	AnyOK() (T, bool)
+	LastOK() (T, bool)
+	// TopK return the k greatest elements in descending order without full sort
+	TopK(k int, comparator types.Comparator[T]) []T
+	// BottomK return the k least elements in ascending order without full sort
+	BottomK(k int, comparator types.Comparator[T]) []T
+	// Sample sample k elements uniformly in one pass with O(k) memory
+	Sample(k int) []T
+	// SampleWeighted sample k elements with probability proportional to weight in one pass
+	SampleWeighted(k int, weight func(T) float64) []T
 	// Cout return count result
 	Count() int64
+
+	// EncodeJSONLines write elements to w as JSON Lines in stream order, return write or stream error
+	EncodeJSONLines(w io.Writer) error
+
+	// Err return the first error met by the latest terminal operate run on this stream, or by stream sources.
+	// Each run records its own errors, so errors of runs on other streams derived from the same source are not reported
+	Err() error
 }
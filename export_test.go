@@ -1,8 +1,12 @@
 package stream_test
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"reflect"
 	"testing"
+	"time"
 
 	"github.com/tr1v3r/stream"
 )
@@ -65,3 +69,172 @@ func TestStream_1(t *testing.T) {
 	// var x []any = []any{"hello", "world"}
 	// stream.SliceOf(x.([]string))
 }
+
+func TestPickOK(t *testing.T) {
+	for name, empty := range map[string]stream.Streamer[int]{
+		"sync":   stream.SliceOf[int](),
+		"async":  stream.SliceOf[int]().Parallel(2),
+		"supply": stream.Of(func() (int, bool) { return 0, false }),
+	} {
+		if _, ok := empty.FirstOK(); ok {
+			t.Errorf("%s: expect FirstOK false on empty stream", name)
+		}
+		if _, ok := empty.LastOK(); ok {
+			t.Errorf("%s: expect LastOK false on empty stream", name)
+		}
+		if _, ok := empty.TakeOK(); ok {
+			t.Errorf("%s: expect TakeOK false on empty stream", name)
+		}
+		if last := empty.Last(); last != 0 {
+			t.Errorf("%s: expect zero Last on empty stream, got %d", name, last)
+		}
+	}
+
+	s := stream.SliceOf(1, 2, 3).Skip(1)
+	if first, ok := s.FirstOK(); !ok || first != 2 {
+		t.Errorf("expect FirstOK 2, got %d %v", first, ok)
+	}
+	if last, ok := s.LastOK(); !ok || last != 3 {
+		t.Errorf("expect LastOK 3, got %d %v", last, ok)
+	}
+	if last, ok := s.Parallel(2).LastOK(); !ok || (last != 2 && last != 3) {
+		t.Errorf("expect async LastOK 2 or 3, got %d %v", last, ok)
+	}
+	if any, ok := s.AnyOK(); !ok || (any != 2 && any != 3) {
+		t.Errorf("expect AnyOK 2 or 3, got %d %v", any, ok)
+	}
+}
+
+func TestInfiniteStream(t *testing.T) {
+	double := func(i int) int { return i * 2 }
+	if result := stream.Repeat(1).Map(double).Filter(func(i int) bool { return i > 0 }).Limit(3).ToSlice(); !reflect.DeepEqual(result, []int{2, 2, 2}) {
+		t.Errorf("expect [2 2 2], got %v", result)
+	}
+	if result := stream.Repeat(1).Skip(5).Pick(0, 4, 2).ToSlice(); !reflect.DeepEqual(result, []int{1, 1, 1}) {
+		t.Errorf("expect [1 1 1], got %v", result)
+	}
+	if first, ok := stream.Repeat(7).FirstOK(); !ok || first != 7 {
+		t.Errorf("expect FirstOK 7, got %d %v", first, ok)
+	}
+
+	// op return the stream its terminal operate ran on
+	for name, op := range map[string]func(stream.Streamer[int]) stream.Streamer[int]{
+		"Count": func(s stream.Streamer[int]) stream.Streamer[int] { s.Count(); return s },
+		"Last":  func(s stream.Streamer[int]) stream.Streamer[int] { s.Last(); return s },
+		"Sort": func(s stream.Streamer[int]) stream.Streamer[int] {
+			p := s.Sort(func(l, r int) int { return l - r })
+			p.First()
+			return p
+		},
+		"Pick": func(s stream.Streamer[int]) stream.Streamer[int] { p := s.Pick(1, -1, 1); p.First(); return p },
+		"Reduce": func(s stream.Streamer[int]) stream.Streamer[int] {
+			m := s.Map(double)
+			m.Reduce(func(l, r int) int { return l + r })
+			return m
+		},
+		"Sample": func(s stream.Streamer[int]) stream.Streamer[int] { s.Sample(3); return s },
+		"TopK": func(s stream.Streamer[int]) stream.Streamer[int] {
+			s.TopK(3, func(l, r int) int { return l - r })
+			return s
+		},
+
+		"async Count": func(s stream.Streamer[int]) stream.Streamer[int] { p := s.Parallel(2); p.Count(); return p },
+		"async Map": func(s stream.Streamer[int]) stream.Streamer[int] {
+			m := s.Parallel(2).Map(double)
+			m.ToSlice()
+			return m
+		},
+		"async Last": func(s stream.Streamer[int]) stream.Streamer[int] { p := s.Parallel(2); p.Last(); return p },
+		"async TopK": func(s stream.Streamer[int]) stream.Streamer[int] {
+			p := s.Skip(1).Parallel(2)
+			p.TopK(3, func(l, r int) int { return l - r })
+			return p
+		},
+	} {
+		if err := op(stream.Repeat(1)).Err(); !errors.Is(err, stream.ErrInfiniteStream) {
+			t.Errorf("%s: expect ErrInfiniteStream, got %v", name, err)
+		}
+	}
+
+	// errors of one run are not reported by another
+	s := stream.Repeat(1)
+	s.Count()
+	limited := s.Limit(3)
+	if result := limited.ToSlice(); !reflect.DeepEqual(result, []int{1, 1, 1}) || limited.Err() != nil {
+		t.Errorf("expect [1 1 1] without error, got %v %v", result, limited.Err())
+	}
+	if err := s.Err(); !errors.Is(err, stream.ErrInfiniteStream) {
+		t.Errorf("expect ErrInfiniteStream of Count kept, got %v", err)
+	}
+}
+
+func TestInfiniteStreamWithContext(t *testing.T) {
+	var i int
+	next := func() int { i++; return i }
+
+	ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	s := stream.Generate(next).WithContext(ctx)
+	if top := s.TopK(3, func(l, r int) int { return l - r }); len(top) != 3 {
+		t.Errorf("expect TopK consuming until cancelled returns 3 elements, got %v", top)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("expect no error for cancellable infinite stream, got %v", err)
+	}
+
+	ctx, cancel = context.WithCancel(context.Background())
+	defer cancel()
+	if first, ok := stream.Generate(next).WithContext(ctx).Filter(func(i int) bool { return i%2 == 0 }).FirstOK(); !ok || first%2 != 0 {
+		t.Errorf("expect lazy Filter pulling until first match, got %d %v", first, ok)
+	}
+
+	ctx, cancel = context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer cancel()
+	if sample := stream.Generate(next).WithContext(ctx).Parallel(2).Sample(2); len(sample) != 2 {
+		t.Errorf("expect async Sample consuming until cancelled returns 2 elements, got %v", sample)
+	}
+	if first := stream.Repeat(1).Parallel(2).First(); first != 1 {
+		t.Errorf("expect async First 1, got %d", first)
+	}
+	if result := stream.Repeat(1).Parallel(2).Pick(0, 4, 2).ToSlice(); !reflect.DeepEqual(result, []int{1, 1, 1}) {
+		t.Errorf("expect async Pick [1 1 1], got %v", result)
+	}
+	if result := stream.Repeat(1).Parallel(2).Skip(5).Limit(3).ToSlice(); !reflect.DeepEqual(result, []int{1, 1, 1}) {
+		t.Errorf("expect async Skip dropping elements as they come, got %v", result)
+	}
+	if count := stream.Range(0, 1000, 1).Parallel(4).WithBatchSize(64).Skip(10).Count(); count != 990 {
+		t.Errorf("expect async Skip then Count 990, got %d", count)
+	}
+}
+
+func TestPeekEager(t *testing.T) {
+	var peeked int
+	if count := stream.SliceOf(1, 2, 3).Peek(func(int) { peeked++ }).Count(); count != 3 || peeked != 3 {
+		t.Errorf("expect Count 3 with 3 elements peeked, got %d %d", count, peeked)
+	}
+}
+
+func TestPositional(t *testing.T) {
+	var i int
+	supply := stream.Of(func() (int, bool) { i++; return i, i <= 10 })
+
+	if count := supply.Count(); count != 10 {
+		t.Errorf("expect Count 10, got %d", count)
+	}
+	for _, s := range []stream.Streamer[int]{stream.SliceOf(1, 2, 3, 4, 5, 6, 7, 8, 9, 10), stream.Of(func() (int, bool) { i++; return i - 11, i <= 21 })} {
+		if result := s.Pick(1, 7, 3).ToSlice(); !reflect.DeepEqual(result, []int{2, 5, 8}) {
+			t.Errorf("expect [2 5 8], got %v", result)
+		}
+	}
+	for _, pick := range [][3]int{{-1, 3, 1}, {5, 3, 1}, {0, 3, 0}, {20, -1, 1}} {
+		if result := stream.SliceOf(1, 2, 3).Pick(pick[0], pick[1], pick[2]).ToSlice(); len(result) != 0 {
+			t.Errorf("Pick%v: expect empty, got %v", pick, result)
+		}
+	}
+	if result := stream.SliceOf(1, 2, 3).Skip(5).ToSlice(); len(result) != 0 {
+		t.Errorf("expect empty after Skip beyond size, got %v", result)
+	}
+	if result := stream.SliceOf(1, 2, 3).Parallel(2).Limit(5).Count(); result != 3 {
+		t.Errorf("expect async Limit keeps 3, got %d", result)
+	}
+}
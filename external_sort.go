@@ -0,0 +1,283 @@
+package stream
+
+import (
+	"bufio"
+	"container/heap"
+	"context"
+	"encoding/gob"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+var (
+	_ Codec = GobCodec{}
+	_ Codec = JSONCodec{}
+)
+
+// defaultRunSize default max elements sorted in memory for one run
+const defaultRunSize = 100000
+
+// Codec encode elements to spill files and decode them back
+type Codec interface {
+	NewEncoder(io.Writer) Encoder
+	NewDecoder(io.Reader) Decoder
+}
+
+// Encoder encode element to underlying writer
+type Encoder interface{ Encode(any) error }
+
+// Decoder decode element from underlying reader
+type Decoder interface{ Decode(any) error }
+
+// GobCodec encoding/gob codec
+type GobCodec struct{}
+
+func (GobCodec) NewEncoder(w io.Writer) Encoder { return gob.NewEncoder(w) }
+func (GobCodec) NewDecoder(r io.Reader) Decoder { return gob.NewDecoder(r) }
+
+// JSONCodec encoding/json codec
+type JSONCodec struct{}
+
+func (JSONCodec) NewEncoder(w io.Writer) Encoder { return json.NewEncoder(w) }
+func (JSONCodec) NewDecoder(r io.Reader) Decoder { return json.NewDecoder(r) }
+
+// ExternalSortOptions options for ExternalSort
+type ExternalSortOptions struct {
+	// RunSize max elements sorted in memory for one run, default 100000
+	RunSize int
+	// TempDir directory to create spill files in, default os.TempDir()
+	TempDir string
+	// Codec encode elements to spill files, default GobCodec
+	Codec Codec
+}
+
+func (o ExternalSortOptions) withDefault() ExternalSortOptions {
+	if o.RunSize <= 0 {
+		o.RunSize = defaultRunSize
+	}
+	if o.Codec == nil {
+		o.Codec = GobCodec{}
+	}
+	return o
+}
+
+// externalSort sort elements from next in runs of opts.RunSize, spill runs to temp files
+// and return an iterator k-way merging runs lazily.
+// Spill files are removed when merge finished, failed or ctx is done, errors are recorded to rec.
+func externalSort[T any](ctx context.Context, rec *errRecorder, next func() (T, bool), cmp types.Comparator[T], opts ExternalSortOptions) iterator[T] {
+	opts = opts.withDefault()
+
+	m := &runMerger[T]{cmp: cmp, codec: opts.Codec}
+	run := make([]T, 0, opts.RunSize)
+	for {
+		t, ok := next()
+		if ok {
+			if run = append(run, t); len(run) < opts.RunSize {
+				continue
+			}
+		}
+
+		// run is full or input is exhausted
+		if len(run) > 0 {
+			sort.Stable(&Sortable[T]{List: run, Cmp: cmp})
+			if !ok && m.dir == "" { // all elements fit in one run, no need to spill
+				return newIterator(run)
+			}
+			if err := m.spill(opts.TempDir, run); err != nil {
+				m.close()
+				rec.record(fmt.Errorf("external sort spill run: %w", err))
+				return newIterator[T](nil)
+			}
+			run = run[:0]
+		}
+		if !ok {
+			break
+		}
+	}
+	if m.dir == "" { // no element at all
+		return newIterator[T](nil)
+	}
+
+	if err := m.open(); err != nil {
+		m.close()
+		rec.record(fmt.Errorf("external sort open run: %w", err))
+		return newIterator[T](nil)
+	}
+	if done := ctx.Done(); done != nil {
+		go func() {
+			select {
+			case <-done:
+				m.close()
+			case <-m.closed:
+			}
+		}()
+	}
+	iter := newSupplyIter(func() (t T, ok bool) {
+		t, ok, err := m.pop()
+		if err != nil {
+			rec.record(fmt.Errorf("external sort merge runs: %w", err))
+		}
+		if !ok {
+			m.close()
+		}
+		return t, ok
+	}, sizeUnknown)
+	iter.stop = m.close // consumer stopping early removes runs as well
+	return iter
+}
+
+// runMerger merge sorted runs spilled to files
+type runMerger[T any] struct {
+	mu sync.Mutex
+
+	cmp   types.Comparator[T]
+	codec Codec
+
+	dir   string
+	paths []string
+	files []*os.File
+	heads runHeap[T]
+
+	once   sync.Once
+	closed chan struct{}
+}
+
+// spill write sorted run to a new file in temp dir
+func (m *runMerger[T]) spill(tempDir string, run []T) (err error) {
+	if m.dir == "" {
+		if m.dir, err = os.MkdirTemp(tempDir, "stream-sort-"); err != nil {
+			return err
+		}
+		m.closed = make(chan struct{})
+	}
+
+	path := filepath.Join(m.dir, fmt.Sprintf("run-%06d", len(m.paths)))
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	m.paths = append(m.paths, path)
+
+	w := bufio.NewWriter(f)
+	enc := m.codec.NewEncoder(w)
+	for i := range run {
+		if err = enc.Encode(&run[i]); err != nil {
+			return err
+		}
+	}
+	if err = w.Flush(); err != nil {
+		return err
+	}
+	return f.Close()
+}
+
+// open open all runs and read their first elements
+func (m *runMerger[T]) open() error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.heads.cmp = m.cmp
+	for index, path := range m.paths {
+		f, err := os.Open(path)
+		if err != nil {
+			return err
+		}
+		m.files = append(m.files, f)
+
+		r := &runReader[T]{index: index, dec: m.codec.NewDecoder(bufio.NewReader(f))}
+		if ok, err := r.advance(); err != nil {
+			return err
+		} else if ok {
+			m.heads.items = append(m.heads.items, r)
+		}
+	}
+	heap.Init(&m.heads)
+	return nil
+}
+
+// pop return the smallest head element of all runs
+func (m *runMerger[T]) pop() (t T, ok bool, err error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.heads.Len() == 0 {
+		return t, false, nil
+	}
+
+	r := m.heads.items[0]
+	t = r.head
+	if ok, err = r.advance(); err != nil {
+		m.heads.items = nil
+		return t, true, err
+	} else if ok {
+		heap.Fix(&m.heads, 0)
+	} else {
+		heap.Pop(&m.heads)
+	}
+	return t, true, nil
+}
+
+// close close all files and remove temp dir, it is safe to call close many times
+func (m *runMerger[T]) close() {
+	if m.dir == "" {
+		return
+	}
+	m.once.Do(func() {
+		m.mu.Lock()
+		defer m.mu.Unlock()
+
+		for _, f := range m.files {
+			_ = f.Close()
+		}
+		m.files, m.heads.items = nil, nil
+		_ = os.RemoveAll(m.dir)
+		close(m.closed)
+	})
+}
+
+// runReader read elements of one run
+type runReader[T any] struct {
+	index int
+	dec   Decoder
+	head  T
+}
+
+func (r *runReader[T]) advance() (bool, error) {
+	var t T
+	if err := r.dec.Decode(&t); err == io.EOF {
+		return false, nil
+	} else if err != nil {
+		return false, err
+	}
+	r.head = t
+	return true, nil
+}
+
+// runHeap min heap of run heads, ties are broken by run index to keep runs order
+type runHeap[T any] struct {
+	cmp   types.Comparator[T]
+	items []*runReader[T]
+}
+
+func (h *runHeap[T]) Len() int { return len(h.items) }
+func (h *runHeap[T]) Less(i, j int) bool {
+	if c := h.cmp(h.items[i].head, h.items[j].head); c != 0 {
+		return c < 0
+	}
+	return h.items[i].index < h.items[j].index
+}
+func (h *runHeap[T]) Swap(i, j int) { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *runHeap[T]) Push(x any)    { h.items = append(h.items, x.(*runReader[T])) }
+func (h *runHeap[T]) Pop() any {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
@@ -1,6 +1,10 @@
 package stream
 
-import "github.com/tr1v3r/stream/types"
+import (
+	"math"
+
+	"github.com/tr1v3r/stream/types"
+)
 
 // SliceOf receive array and initlize streamer
 func SliceOf[T any](slice ...T) Streamer[T] {
@@ -9,12 +13,12 @@ func SliceOf[T any](slice ...T) Streamer[T] {
 
 // Of create a new stream with supply
 func Of[T any](supply types.Supplier[T]) Streamer[T] {
-	return newStreamer[T](&supplyIter[T]{supply: supply})
+	return newStreamer[T](newSupplyIter(supply, sizeUnknown))
 }
 
 // Repeat create a new stream with unlimit repeated data items
 func Repeat[T any](t T) Streamer[T] {
-	return newStreamer[T](&supplyIter[T]{supply: func() (T, bool) { return t, true }})
+	return newStreamer[T](newSupplyIter(func() (T, bool) { return t, true }, sizeInfinite))
 }
 
 // RepeatN create a new stream with n times repeated data items
@@ -22,6 +26,91 @@ func RepeatN[T any](t T, count int64) Streamer[T] {
 	return Repeat(t).Limit(count)
 }
 
+// Range create a new stream of integers from start to end exclusive by step,
+// step may be negative for descending range, zero step or step away from end create an empty stream
+func Range[N types.Integer](start, end, step N) Streamer[N] {
+	// count in uint64 after widening, N may overflow on end-start and count may overflow int64
+	var count uint64
+	switch {
+	case step > 0 && start < end:
+		count = (distance(start, end)-1)/uint64(step) + 1
+	case step < 0 && start > end:
+		count = (distance(end, start)-1)/(^uint64(int64(step))+1) + 1
+	}
+	size := int64(count)
+	if count > math.MaxInt64 {
+		size = sizeUnknown
+	}
+	return newStreamer[N](newGenIter(func() types.Supplier[N] {
+		cur, left := start, count
+		return func() (n N, ok bool) {
+			if left == 0 {
+				return n, false
+			}
+			n, left = cur, left-1
+			if left > 0 {
+				cur += step
+			}
+			return n, true
+		}
+	}, size))
+}
+
+// distance return hi-lo for lo < hi without overflow
+func distance[N types.Integer](lo, hi N) uint64 {
+	if lo < 0 && hi >= 0 {
+		return uint64(hi) + (^uint64(int64(lo)) + 1)
+	}
+	return uint64(int64(hi) - int64(lo))
+}
+
+// Iterate create a new infinite stream of seed, next(seed), next(next(seed)), ...
+func Iterate[T any](seed T, next func(T) T) Streamer[T] {
+	return IterateWhile(seed, nil, next)
+}
+
+// IterateWhile create a new stream like Iterate, which ends at the first element not satisfying hasNext,
+// nil hasNext means infinite
+func IterateWhile[T any](seed T, hasNext types.Judge[T], next func(T) T) Streamer[T] {
+	size := int64(sizeUnknown)
+	if hasNext == nil {
+		size, hasNext = sizeInfinite, func(T) bool { return true }
+	}
+	return newStreamer[T](newGenIter(func() types.Supplier[T] {
+		cur, started := seed, false
+		return func() (t T, ok bool) {
+			if started {
+				cur = next(cur)
+			}
+			if started = true; !hasNext(cur) {
+				return t, false
+			}
+			return cur, true
+		}
+	}, size))
+}
+
+// Generate create a new infinite stream of elements built by generate
+func Generate[T any](generate func() T) Streamer[T] {
+	return newStreamer[T](newSupplyIter(func() (T, bool) { return generate(), true }, sizeInfinite))
+}
+
+// Unfold create a new stream from state, unfold return next element and state, or false to end the stream
+func Unfold[T, S any](state S, unfold func(S) (T, S, bool)) Streamer[T] {
+	return newStreamer[T](newGenIter(func() types.Supplier[T] {
+		cur, done := state, false
+		return func() (t T, ok bool) {
+			if done {
+				return t, false
+			}
+			if t, cur, ok = unfold(cur); !ok {
+				done = true
+			}
+			return t, ok
+		}
+	}, sizeUnknown))
+}
+
 // Concat concat streamers
 func Concat[T any](dst Streamer[T], srcs ...Streamer[T]) Streamer[T] {
 	for _, src := range srcs {
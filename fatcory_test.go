@@ -0,0 +1,99 @@
+package stream_test
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestRange(t *testing.T) {
+	for _, c := range []struct {
+		start, end, step int
+		expect           []int
+	}{
+		{0, 5, 1, []int{0, 1, 2, 3, 4}},
+		{0, 10, 3, []int{0, 3, 6, 9}},
+		{5, 0, -2, []int{5, 3, 1}},
+		{0, 5, -1, nil},
+		{0, 5, 0, nil},
+	} {
+		s := stream.Range(c.start, c.end, c.step)
+		if count := s.Count(); count != int64(len(c.expect)) {
+			t.Errorf("Range(%d, %d, %d): expect Count %d, got %d", c.start, c.end, c.step, len(c.expect), count)
+		}
+		if result := s.ToSlice(); !reflect.DeepEqual(result, c.expect) {
+			t.Errorf("Range(%d, %d, %d): expect %v, got %v", c.start, c.end, c.step, c.expect, result)
+		}
+	}
+
+	if result := stream.Range[int8](-100, 100, 50).ToSlice(); !reflect.DeepEqual(result, []int8{-100, -50, 0, 50}) {
+		t.Errorf("expect [-100 -50 0 50], got %v", result)
+	}
+	if result := stream.Range[int8](127, -128, -128).ToSlice(); !reflect.DeepEqual(result, []int8{127, -1}) {
+		t.Errorf("expect [127 -1], got %v", result)
+	}
+	if count := stream.Range[int8](-128, 127, 1).Count(); count != 255 {
+		t.Errorf("expect Count 255, got %d", count)
+	}
+	if count := stream.Range[uint64](0, 1<<63+10, 1<<62).Count(); count != 3 {
+		t.Errorf("expect Count 3, got %d", count)
+	}
+	if result := stream.Range[uint8](250, 255, 2).ToSlice(); !reflect.DeepEqual(result, []uint8{250, 252, 254}) {
+		t.Errorf("expect [250 252 254], got %v", result)
+	}
+	if result := stream.Range[int64](0, 1<<20, 1).Pick(3, -1, 1<<18).ToSlice(); len(result) != 4 || result[3] != 3+3<<18 {
+		t.Errorf("expect 4 picked elements, got %v", result)
+	}
+
+	// spans wider than MaxInt64 elements
+	if result := stream.Range[uint64](0, math.MaxUint64, 1).Limit(3).ToSlice(); !reflect.DeepEqual(result, []uint64{0, 1, 2}) {
+		t.Errorf("expect [0 1 2], got %v", result)
+	}
+	if result := stream.Range[int64](math.MinInt64, math.MaxInt64, 1).Limit(2).ToSlice(); !reflect.DeepEqual(result, []int64{math.MinInt64, math.MinInt64 + 1}) {
+		t.Errorf("expect [MinInt64 MinInt64+1], got %v", result)
+	}
+	if result := stream.Range[int64](math.MaxInt64, math.MinInt64, -1).Limit(2).ToSlice(); !reflect.DeepEqual(result, []int64{math.MaxInt64, math.MaxInt64 - 1}) {
+		t.Errorf("expect [MaxInt64 MaxInt64-1], got %v", result)
+	}
+	if result := stream.Range[int64](math.MinInt64, math.MaxInt64, 1<<62).ToSlice(); !reflect.DeepEqual(result, []int64{math.MinInt64, -1 << 62, 0, 1 << 62}) {
+		t.Errorf("expect 4 elements across int64 range, got %v", result)
+	}
+}
+
+func TestGenerators(t *testing.T) {
+	powers := stream.Iterate(1, func(i int) int { return i * 2 })
+	if result := powers.Limit(5).ToSlice(); !reflect.DeepEqual(result, []int{1, 2, 4, 8, 16}) {
+		t.Errorf("Iterate: expect [1 2 4 8 16], got %v", result)
+	}
+	if result := powers.Skip(2).Limit(2).ToSlice(); !reflect.DeepEqual(result, []int{4, 8}) {
+		t.Errorf("Iterate: expect reusable source [4 8], got %v", result)
+	}
+
+	collatz := stream.IterateWhile(6, func(i int) bool { return i != 1 }, func(i int) int {
+		if i%2 == 0 {
+			return i / 2
+		}
+		return 3*i + 1
+	})
+	if result := collatz.ToSlice(); !reflect.DeepEqual(result, []int{6, 3, 10, 5, 16, 8, 4, 2}) {
+		t.Errorf("IterateWhile: expect [6 3 10 5 16 8 4 2], got %v", result)
+	}
+	if count := collatz.Count(); count != 8 {
+		t.Errorf("IterateWhile: expect Count 8, got %d", count)
+	}
+
+	var n int
+	if result := stream.Generate(func() int { n++; return n }).Limit(3).ToSlice(); !reflect.DeepEqual(result, []int{1, 2, 3}) {
+		t.Errorf("Generate: expect [1 2 3], got %v", result)
+	}
+
+	fib := stream.Unfold([2]int{0, 1}, func(s [2]int) (int, [2]int, bool) { return s[0], [2]int{s[1], s[0] + s[1]}, s[0] < 20 })
+	if result := fib.ToSlice(); !reflect.DeepEqual(result, []int{0, 1, 1, 2, 3, 5, 8, 13}) {
+		t.Errorf("Unfold: expect [0 1 1 2 3 5 8 13], got %v", result)
+	}
+	if last := fib.Last(); last != 13 {
+		t.Errorf("Unfold: expect Last 13, got %d", last)
+	}
+}
@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// defaultPollInterval default interval Follow checks file for new lines
+const defaultPollInterval = time.Second
+
+// Clock source of time, replaceable in tests
+type Clock interface {
+	Now() time.Time
+}
+
+// Timer source of waits, replaceable in tests
+type Timer interface {
+	After(time.Duration) <-chan time.Time
+}
+
+// systemClock Clock and Timer of package time
+type systemClock struct{}
+
+func (systemClock) Now() time.Time                         { return time.Now() }
+func (systemClock) After(d time.Duration) <-chan time.Time { return time.After(d) }
+
+// FollowOptions options for Follow
+type FollowOptions struct {
+	// PollInterval interval to check file for new lines, default 1s
+	PollInterval time.Duration
+	// Timer wait between polls, default system timer
+	Timer Timer
+	// FromStart emit lines already in file, default start from the end of file
+	FromStart bool
+}
+
+func (o FollowOptions) withDefault() FollowOptions {
+	if o.PollInterval <= 0 {
+		o.PollInterval = defaultPollInterval
+	}
+	if o.Timer == nil {
+		o.Timer = systemClock{}
+	}
+	return o
+}
+
+// Follow create a new unbounded stream of lines appended to file at path, like tail -F.
+// File is polled until stream context is cancelled, lines are emitted one by one as they are read.
+// Truncated file is read again from start, rotated file is reopened by path when its identity changes,
+// missing file is waited for. Line endings are stripped.
+func Follow(path string, opts ...FollowOptions) Streamer[string] {
+	var opt FollowOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	opt = opt.withDefault()
+
+	s := newStreamer[string](nil)
+	rec := recorderOf(s.ctx)
+	s.source = newBoundIter(func(ctx context.Context) (types.Supplier[string], func()) {
+		f := &follower{path: path, opts: opt, rec: rec, fromStart: opt.FromStart}
+		return func() (string, bool) { return f.next(ctx) }, f.close
+	}, sizeInfinite)
+	return s
+}
+
+// follower read lines of followed file
+type follower struct {
+	path string
+	opts FollowOptions
+	rec  *errRecorder
+
+	file      *os.File
+	info      fs.FileInfo
+	reader    *bufio.Reader
+	offset    int64
+	partial   strings.Builder // incomplete last line
+	fromStart bool
+}
+
+func (f *follower) next(ctx context.Context) (string, bool) {
+	defer func() {
+		if ctx.Err() != nil {
+			f.close()
+		}
+	}()
+	for ctx.Err() == nil {
+		if f.file == nil {
+			if err := f.open(); err != nil {
+				if errors.Is(err, fs.ErrNotExist) { // file created later is read from start
+					f.fromStart = true
+				} else {
+					f.rec.record(fmt.Errorf("follow %s: %w", f.path, err))
+				}
+				if !f.wait(ctx) {
+					return "", false
+				}
+				continue
+			}
+		}
+
+		line, err := f.reader.ReadString('\n')
+		f.offset += int64(len(line))
+		f.partial.WriteString(line)
+		if err == nil {
+			return f.takeLine(), true
+		}
+		if err != io.EOF {
+			f.rec.record(fmt.Errorf("follow %s: %w", f.path, err))
+		}
+
+		switch info, err := os.Stat(f.path); {
+		case err != nil || !os.SameFile(info, f.info): // rotated or removed, emit last incomplete line of old file
+			f.close()
+			f.fromStart = true
+			if f.partial.Len() > 0 {
+				return f.takeLine(), true
+			}
+		case info.Size() < f.offset: // truncated
+			if _, err = f.file.Seek(0, io.SeekStart); err != nil {
+				f.rec.record(fmt.Errorf("follow %s: %w", f.path, err))
+				f.close()
+			} else {
+				f.reader.Reset(f.file)
+				f.offset = 0
+				f.partial.Reset()
+			}
+		case info.Size() > f.offset: // appended after read
+		default:
+			if !f.wait(ctx) {
+				return "", false
+			}
+		}
+	}
+	return "", false
+}
+
+// open open file, seek to the end unless following from start
+func (f *follower) open() (err error) {
+	if f.file, err = os.Open(f.path); err != nil {
+		return err
+	}
+	if f.info, err = f.file.Stat(); err != nil {
+		f.close()
+		return err
+	}
+	f.offset = 0
+	if !f.fromStart {
+		if f.offset, err = f.file.Seek(0, io.SeekEnd); err != nil {
+			f.close()
+			return err
+		}
+	}
+	f.reader = bufio.NewReader(f.file)
+	return nil
+}
+
+func (f *follower) close() {
+	if f.file != nil {
+		_ = f.file.Close()
+		f.file = nil
+	}
+}
+
+func (f *follower) takeLine() string {
+	line := strings.TrimSuffix(strings.TrimSuffix(f.partial.String(), "\n"), "\r")
+	f.partial.Reset()
+	return line
+}
+
+// wait wait a poll interval, report false if ctx is done
+func (f *follower) wait(ctx context.Context) bool {
+	select {
+	case <-ctx.Done():
+		return false
+	case <-f.opts.Timer.After(f.opts.PollInterval):
+		return true
+	}
+}
@@ -0,0 +1,170 @@
+package stream_test
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/stream"
+)
+
+// manualTimer fire After only when test ticks
+type manualTimer struct{ ticks chan time.Time }
+
+func (t *manualTimer) After(time.Duration) <-chan time.Time { return t.ticks }
+
+func TestFollow(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("old\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	appendFile := func(text string) {
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		_, _ = f.WriteString(text)
+		_ = f.Close()
+	}
+
+	timer := &manualTimer{ticks: make(chan time.Time)}
+	ctx, cancel := context.WithCancel(context.Background())
+	lines, done := make(chan string, 10), make(chan struct{})
+	go func() {
+		defer close(done)
+		stream.Follow(path, stream.FollowOptions{Timer: timer}).WithContext(ctx).
+			Filter(func(line string) bool { return line != "" }).
+			ForEach(func(line string) { lines <- line })
+	}()
+	expect := func(step, line string) {
+		select {
+		case got := <-lines:
+			if got != line {
+				t.Errorf("%s: expect %q, got %q", step, line, got)
+			}
+		case <-time.After(5 * time.Second):
+			t.Fatalf("%s: timeout waiting for %q", step, line)
+		}
+	}
+
+	timer.ticks <- time.Time{}
+	appendFile("a\nb")
+	timer.ticks <- time.Time{}
+	expect("append", "a")
+	appendFile("2\n")
+	timer.ticks <- time.Time{}
+	expect("partial line", "b2")
+
+	if err := os.WriteFile(path, []byte("t1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	timer.ticks <- time.Time{}
+	expect("truncate", "t1")
+
+	if err := os.Rename(path, path+".1"); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(path, []byte("n1\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	timer.ticks <- time.Time{}
+	expect("rotate", "n1")
+
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Errorf("expect Follow stopped after cancel")
+	}
+}
+
+func TestFollowSequential(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, []byte("\nfirst\nsecond\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	// lines are pulled one by one, no context needed to stop after the first match
+	s := stream.Follow(path, stream.FollowOptions{FromStart: true, Timer: &manualTimer{}}).Filter(func(line string) bool { return line != "" })
+	if line, ok := s.FirstOK(); !ok || line != "first" {
+		t.Errorf("expect first line, got %q %v", line, ok)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("expect no error, got %v", err)
+	}
+}
+
+func TestFollowParallel(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "app.log")
+	if err := os.WriteFile(path, nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	baseline := runtime.NumGoroutine()
+
+	// keep appending while stream runs
+	quit, written := make(chan struct{}), make(chan struct{})
+	go func() {
+		defer close(written)
+		f, _ := os.OpenFile(path, os.O_APPEND|os.O_WRONLY, 0o644)
+		defer f.Close()
+		for i := 0; ; i++ {
+			select {
+			case <-quit:
+				return
+			case <-time.After(time.Millisecond):
+				_, _ = f.WriteString("line " + strconv.Itoa(i) + "\n")
+			}
+		}
+	}()
+
+	var received atomic.Int64
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		stream.Follow(path, stream.FollowOptions{PollInterval: time.Millisecond, FromStart: true}).WithContext(ctx).
+			Parallel(4).
+			ForEach(func(string) { received.Add(1) })
+	}()
+
+	for deadline := time.Now().Add(5 * time.Second); received.Load() < 10; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("timeout waiting for lines, got %d", received.Load())
+		}
+	}
+	cancel()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("expect Follow stopped after cancel while lines are appended")
+	}
+	close(quit)
+	<-written
+
+	for deadline := time.Now().Add(5 * time.Second); runtime.NumGoroutine() > baseline; time.Sleep(time.Millisecond) {
+		if time.Now().After(deadline) {
+			t.Fatalf("expect no goroutine left, got %d over baseline %d", runtime.NumGoroutine(), baseline)
+		}
+	}
+	// checked once goroutines are gone, before finalizer of leaked file may close it
+	if n := openedFiles(t, path); n != 0 {
+		t.Errorf("expect followed file closed, still opened %d times", n)
+	}
+}
+
+// openedFiles return number of file descriptors of the process opened on path
+func openedFiles(t *testing.T, path string) (n int) {
+	fds, err := os.ReadDir("/proc/self/fd")
+	if err != nil {
+		t.Skip("open files not listed on this platform")
+	}
+	for _, fd := range fds {
+		if target, err := os.Readlink(filepath.Join("/proc/self/fd", fd.Name())); err == nil && target == path {
+			n++
+		}
+	}
+	return n
+}
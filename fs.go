@@ -0,0 +1,182 @@
+package stream
+
+import (
+	"fmt"
+	"io/fs"
+	"path"
+	"strings"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// FileEntry file or directory found by WalkFS
+type FileEntry struct {
+	fs.DirEntry
+
+	// Path slash separated path in file system, including root
+	Path string
+	// Depth depth below root, entries directly in root have depth 1
+	Depth int
+	// Content file content, only loaded with WalkOptions.LoadContent
+	Content []byte
+
+	fsys fs.FS
+}
+
+// Open open the file in file system
+func (e FileEntry) Open() (fs.File, error) { return e.fsys.Open(e.Path) }
+
+// ReadFile read the whole file, Content is returned if loaded
+func (e FileEntry) ReadFile() ([]byte, error) {
+	if e.Content != nil {
+		return e.Content, nil
+	}
+	return fs.ReadFile(e.fsys, e.Path)
+}
+
+// WalkOptions options for WalkFS
+type WalkOptions struct {
+	// Include glob patterns entries must match any of, empty means all.
+	// Patterns containing / are matched against Path, others against base name.
+	Include []string
+	// Exclude glob patterns of entries to drop, excluded directories are not walked into
+	Exclude []string
+	// MaxDepth max depth to walk, 0 means unlimited
+	MaxDepth int
+	// Dirs emit directories as well as files
+	Dirs bool
+	// LoadContent read file content into FileEntry.Content
+	LoadContent bool
+}
+
+func (o WalkOptions) validate() error {
+	for _, pattern := range append(append([]string(nil), o.Include...), o.Exclude...) {
+		if _, err := path.Match(pattern, ""); err != nil {
+			return fmt.Errorf("walk pattern %q: %w", pattern, err)
+		}
+	}
+	return nil
+}
+
+func (o WalkOptions) included(e *FileEntry) bool {
+	return len(o.Include) == 0 || matchAny(o.Include, e.Path)
+}
+
+func (o WalkOptions) excluded(e *FileEntry) bool { return matchAny(o.Exclude, e.Path) }
+
+func matchAny(patterns []string, name string) bool {
+	for _, pattern := range patterns {
+		target := name
+		if !strings.Contains(pattern, "/") {
+			target = path.Base(name)
+		}
+		if ok, _ := path.Match(pattern, target); ok {
+			return true
+		}
+	}
+	return false
+}
+
+// WalkFS create a new stream of entries under root in fsys, walked lazily in lexical order like fs.WalkDir.
+// Directories are read only when walked into, unreadable directories and files are recorded to Err and skipped.
+func WalkFS(fsys fs.FS, root string, opts ...WalkOptions) Streamer[FileEntry] {
+	var opt WalkOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	s := newStreamer[FileEntry](nil)
+	rec := recorderOf(s.ctx)
+	if err := opt.validate(); err != nil {
+		rec.record(err)
+		s.source = newIterator[FileEntry](nil)
+		return s
+	}
+
+	s.source = newGenIter(func() types.Supplier[FileEntry] {
+		w := &walker{fsys: fsys, opts: opt, rec: rec}
+		return func() (FileEntry, bool) {
+			if !w.started {
+				w.start(root)
+			}
+			return w.next()
+		}
+	}, sizeUnknown)
+	return s
+}
+
+// walker walk file system with a stack of unvisited entries of each directory
+type walker struct {
+	fsys fs.FS
+	opts WalkOptions
+	rec  *errRecorder
+
+	started bool
+	stack   [][]FileEntry
+}
+
+func (w *walker) start(root string) {
+	w.started = true
+
+	info, err := fs.Stat(w.fsys, root)
+	if err != nil {
+		w.rec.record(fmt.Errorf("walk %s: %w", root, err))
+		return
+	}
+	if info.IsDir() {
+		w.push(root, 0)
+	} else {
+		w.stack = append(w.stack, []FileEntry{{DirEntry: fs.FileInfoToDirEntry(info), Path: root, fsys: w.fsys}})
+	}
+}
+
+// push read directory and push its entries
+func (w *walker) push(dir string, depth int) {
+	entries, err := fs.ReadDir(w.fsys, dir)
+	if err != nil {
+		w.rec.record(fmt.Errorf("walk %s: %w", dir, err))
+		return
+	}
+	frame := make([]FileEntry, len(entries))
+	for i, entry := range entries {
+		frame[i] = FileEntry{DirEntry: entry, Path: path.Join(dir, entry.Name()), Depth: depth + 1, fsys: w.fsys}
+	}
+	w.stack = append(w.stack, frame)
+}
+
+func (w *walker) next() (FileEntry, bool) {
+	for len(w.stack) > 0 {
+		top := len(w.stack) - 1
+		if len(w.stack[top]) == 0 {
+			w.stack = w.stack[:top]
+			continue
+		}
+		entry := w.stack[top][0]
+		w.stack[top] = w.stack[top][1:]
+
+		if w.opts.excluded(&entry) {
+			continue
+		}
+		if entry.IsDir() {
+			if w.opts.MaxDepth <= 0 || entry.Depth < w.opts.MaxDepth {
+				w.push(entry.Path, entry.Depth)
+			}
+			if !w.opts.Dirs {
+				continue
+			}
+		}
+		if !w.opts.included(&entry) {
+			continue
+		}
+		if w.opts.LoadContent && !entry.IsDir() {
+			content, err := fs.ReadFile(w.fsys, entry.Path)
+			if err != nil {
+				w.rec.record(fmt.Errorf("walk %s: %w", entry.Path, err))
+				continue
+			}
+			entry.Content = content
+		}
+		return entry, true
+	}
+	return FileEntry{}, false
+}
@@ -0,0 +1,64 @@
+package stream_test
+
+import (
+	"crypto/sha256"
+	"errors"
+	"io/fs"
+	"reflect"
+	"testing"
+	"testing/fstest"
+
+	"github.com/tr1v3r/stream"
+)
+
+var testFS = fstest.MapFS{
+	"a.txt":              {Data: []byte("a")},
+	"b.log":              {Data: []byte("b")},
+	"sub/c.txt":          {Data: []byte("c")},
+	"sub/deep/d.txt":     {Data: []byte("d")},
+	"vendor/e.txt":       {Data: []byte("e")},
+	"sub/deep/more/f.go": {Data: []byte("f")},
+}
+
+func paths(entries []stream.FileEntry) (results []string) {
+	for _, e := range entries {
+		results = append(results, e.Path)
+	}
+	return results
+}
+
+func TestWalkFS(t *testing.T) {
+	all := stream.WalkFS(testFS, ".")
+	if result := paths(all.ToSlice()); !reflect.DeepEqual(result, []string{"a.txt", "b.log", "sub/c.txt", "sub/deep/d.txt", "sub/deep/more/f.go", "vendor/e.txt"}) {
+		t.Errorf("expect all files in lexical order, got %v", result)
+	}
+	if count := all.Count(); count != 6 {
+		t.Errorf("expect reusable walk of 6 files, got %d", count)
+	}
+
+	result := paths(stream.WalkFS(testFS, ".", stream.WalkOptions{Include: []string{"*.txt"}, Exclude: []string{"vendor"}, MaxDepth: 2}).ToSlice())
+	if !reflect.DeepEqual(result, []string{"a.txt", "sub/c.txt"}) {
+		t.Errorf("filter: expect [a.txt sub/c.txt], got %v", result)
+	}
+
+	result = paths(stream.WalkFS(testFS, "sub", stream.WalkOptions{Dirs: true, Include: []string{"sub/deep*"}}).ToSlice())
+	if !reflect.DeepEqual(result, []string{"sub/deep"}) {
+		t.Errorf("dirs: expect [sub/deep], got %v", result)
+	}
+
+	sums := stream.WalkFS(testFS, ".", stream.WalkOptions{LoadContent: true}).Parallel(4).
+		Map(func(e stream.FileEntry) stream.FileEntry {
+			sum := sha256.Sum256(e.Content)
+			e.Content = sum[:]
+			return e
+		}).
+		ToSlice()
+	if len(sums) != 6 || len(sums[0].Content) != sha256.Size {
+		t.Errorf("expect 6 hashed files, got %d", len(sums))
+	}
+
+	missing := stream.WalkFS(testFS, "nope")
+	if count := missing.Count(); count != 0 || !errors.Is(missing.Err(), fs.ErrNotExist) {
+		t.Errorf("expect ErrNotExist, got %d %v", count, missing.Err())
+	}
+}
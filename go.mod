@@ -1,5 +1,5 @@
 module github.com/tr1v3r/stream
 
-go 1.20
+go 1.21
 
 require github.com/tr1v3r/pkg v0.0.20
@@ -0,0 +1,103 @@
+package stream
+
+import (
+	"encoding/binary"
+	"fmt"
+	"hash/fnv"
+	"hash/maphash"
+	"math"
+	"reflect"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// shardSeed seed of shardHash, shards only live in one process
+var shardSeed = maphash.MakeSeed()
+
+// hashKey return 64-bit hash of key, stable across processes for the same key except pointers,
+// which are hashed by address so that distinct pointers never collapse
+func hashKey(key any) uint64 {
+	h := fnv.New64a()
+	var buf [8]byte
+	writeUint64 := func(x uint64) {
+		binary.LittleEndian.PutUint64(buf[:], x)
+		_, _ = h.Write(buf[:])
+	}
+	switch k := key.(type) {
+	case string:
+		_, _ = h.Write([]byte(k))
+	case sprintKey: // tagged apart from string of the same text
+		_, _ = h.Write([]byte{0})
+		_, _ = h.Write([]byte(k))
+	case []byte:
+		_, _ = h.Write(k)
+	case types.Unique:
+		_, _ = h.Write([]byte(k.Key()))
+	case bool:
+		if k {
+			writeUint64(1)
+		} else {
+			writeUint64(0)
+		}
+	case int:
+		writeUint64(uint64(k))
+	case int8:
+		writeUint64(uint64(k))
+	case int16:
+		writeUint64(uint64(k))
+	case int32:
+		writeUint64(uint64(k))
+	case int64:
+		writeUint64(uint64(k))
+	case uint:
+		writeUint64(uint64(k))
+	case uint8:
+		writeUint64(uint64(k))
+	case uint16:
+		writeUint64(uint64(k))
+	case uint32:
+		writeUint64(uint64(k))
+	case uint64:
+		writeUint64(k)
+	case uintptr:
+		writeUint64(uint64(k))
+	case float32:
+		writeUint64(uint64(math.Float32bits(k)))
+	case float64:
+		writeUint64(math.Float64bits(k))
+	default:
+		switch v := reflect.ValueOf(k); v.Kind() {
+		case reflect.Pointer, reflect.UnsafePointer, reflect.Chan:
+			writeUint64(uint64(v.Pointer()))
+		default:
+			_, _ = fmt.Fprint(h, k)
+		}
+	}
+	return mix64(h.Sum64())
+}
+
+// shardHash return hash of key for picking a shard, fast for strings and integers
+func shardHash[K comparable](key K) uint64 {
+	switch k := any(key).(type) {
+	case string:
+		return maphash.String(shardSeed, k)
+	case int:
+		return mix64(uint64(k))
+	case int64:
+		return mix64(uint64(k))
+	case uint64:
+		return mix64(k)
+	default:
+		return hashKey(k)
+	}
+}
+
+// mix64 finalize hash to spread bits, splitmix64 finalizer
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+	return x
+}
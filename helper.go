@@ -1,7 +1,7 @@
 package stream
 
 import (
-	"fmt"
+	"context"
 
 	"github.com/tr1v3r/stream/types"
 )
@@ -22,20 +22,75 @@ func AnyTo[T any](data ...any) types.Collector[any] {
 	return To(func(d any) T { return d.(T) })
 }
 
-func distinctJudge[T any]() types.Judge[T] {
-	var keyMap = make(map[string]struct{})
-	return func(t T) bool {
-		var key string
-		if keyer, ok := any(t).(types.Unique); ok {
-			key = keyer.Key()
-		} else {
-			key = fmt.Sprint(t)
+// pullAll return function pulling all elements of s one by one in stream order, for sinks consuming serially,
+// and function stopping s, which must be called once pulling ends.
+// Known infinite stream without a cancellable context is failed with ErrInfiniteStream.
+func pullAll[T any](s Streamer[T]) (next func() (T, bool), stop func()) {
+	switch s := s.(type) {
+	case *streamer[T]:
+		if source, rec := s.run(); s.finite(rec, source) {
+			return s.pull(source), func() { closeIter(source) }
 		}
-
-		if _, ok := keyMap[key]; !ok {
-			keyMap[key] = struct{}{}
-			return true
+	case *asyncStreamer[T]:
+		if rec := s.begin(); s.finite(rec) {
+			return s.pull(rec)
 		}
-		return false
+	default:
+		return supplierOf(newIterator(s.ToSlice())), func() {}
+	}
+	return func() (t T, ok bool) { return t, false }, func() {}
+}
+
+// pullOf return function pulling elements of s one by one in stream order as part of run recording errors to rec,
+// and function stopping s, which must be called once pulling ends
+func pullOf[T any](s Streamer[T], rec *errRecorder) (next func() (T, bool), stop func()) {
+	switch s := s.(type) {
+	case *streamer[T]:
+		source := s.runWith(rec)
+		return s.pull(source), func() { closeIter(source) }
+	case *asyncStreamer[T]:
+		return s.pull(rec)
+	default:
+		return supplierOf(newIterator(s.ToSlice())), func() {}
 	}
 }
+
+// contextOf return context of s
+func contextOf[T any](s Streamer[T]) context.Context {
+	switch s := s.(type) {
+	case *streamer[T]:
+		return s.ctx
+	case *asyncStreamer[T]:
+		return s.ctx
+	default:
+		return ctx
+	}
+}
+
+// derive return stream of R sharing context of s, its supplier is built by build on elements of s for each run,
+// s runs as part of the run of derived stream and errors of both are recorded to recorder of the run
+func derive[T, R any](s Streamer[T], build func(next func() (T, bool), rec *errRecorder) types.Supplier[R]) Streamer[R] {
+	sctx := contextOf(s)
+	source := newBoundIter(func(ctx context.Context) (types.Supplier[R], func()) {
+		rec := runRecorderOf(ctx)
+		if rec == nil { // not started by a run
+			rec = newRunRecorder(sctx)
+		}
+
+		var supply types.Supplier[R]
+		stop := func() {}
+		return func() (R, bool) {
+			if supply == nil { // pull s lazily, so that nothing runs before stream runs
+				var next func() (T, bool)
+				next, stop = pullOf(s, rec)
+				supply = build(next, rec)
+			}
+			r, ok := supply()
+			if !ok {
+				stop()
+			}
+			return r, ok
+		}, func() { stop() }
+	}, sizeUnknown)
+	return wrapStreamer[R](source, func(_ *errRecorder, iter iterator[R]) iterator[R] { return iter }).WithContext(sctx)
+}
@@ -1,6 +1,7 @@
 package stream
 
 import (
+	"context"
 	"sync"
 
 	"github.com/tr1v3r/stream/types"
@@ -14,9 +15,14 @@ var (
 	_ iterator[int] = new(deadIter[int])
 )
 
+const (
+	sizeUnknown  int64 = -1 // size is unknown until iterator exhausted
+	sizeInfinite int64 = -2 // iterator never exhausts
+)
+
 // iterator 迭代器
 type iterator[T any] interface {
-	// Size return data size
+	// Size return data size, sizeUnknown or sizeInfinite if not known
 	Size() int64
 	// HasNext return true if iterator has next 1
 	HasNext() bool
@@ -48,6 +54,44 @@ func newIterator[T any](data []T) iterator[T] {
 	}
 }
 
+// leftSize return number of elements left in iter, negative if not known
+func leftSize[T any](iter iterator[T]) int64 {
+	if size := iter.Size(); size < 0 {
+		return size
+	}
+	return iter.Size() - iter.CurIndex()
+}
+
+// supplierOf return supplier supplying elements left in iter
+func supplierOf[T any](iter iterator[T]) types.Supplier[T] {
+	return func() (t T, ok bool) {
+		if iter.HasNext() {
+			return iter.Next(), true
+		}
+		return t, false
+	}
+}
+
+// closeIter release resources held by iter, for consumers stopping before iter exhausted
+func closeIter[T any](iter iterator[T]) {
+	if c, ok := iter.(interface{ close() }); ok {
+		c.close()
+	}
+}
+
+// chainSupplier return supplier supplying from suppliers in order
+func chainSupplier[T any](suppliers ...types.Supplier[T]) types.Supplier[T] {
+	return func() (t T, ok bool) {
+		for len(suppliers) > 0 {
+			if t, ok = suppliers[0](); ok {
+				return t, true
+			}
+			suppliers = suppliers[1:]
+		}
+		return t, false
+	}
+}
+
 // meta iterator元数据
 type meta struct {
 	current int64 // range 0 to size-1
@@ -72,10 +116,14 @@ func (m *meta) HasNextN(n int64) bool {
 // Cur return current index
 func (m *meta) CurIndex() int64 { return m.current }
 
-// nextIndexN return next index, return -1 if next index out of range
+// nextIndexN return next index, return -1 if next index out of range and move to the end
 // n must be positive
 func (m *meta) nextIndexN(n int64) (index int64) {
-	if n <= 0 || m.current+n-1 >= m.size {
+	if n <= 0 {
+		return -1
+	}
+	if m.current+n-1 >= m.size {
+		m.current = m.size
 		return -1
 	}
 	m.current += n
@@ -88,8 +136,13 @@ type staticIter[T any] struct {
 	source []T
 }
 
-func (i *staticIter[T]) Next() T         { return i.NextN(1) }
-func (i *staticIter[T]) NextN(n int64) T { return i.source[i.nextIndexN(n)] }
+func (i *staticIter[T]) Next() T { return i.NextN(1) }
+func (i *staticIter[T]) NextN(n int64) (t T) {
+	if index := i.nextIndexN(n); index >= 0 {
+		return i.source[index]
+	}
+	return t
+}
 func (i *staticIter[T]) Left() (results []T) {
 	for index := i.nextIndexN(1); index != -1; index = i.nextIndexN(1) {
 		results = append(results, i.source[index])
@@ -100,12 +153,7 @@ func (i staticIter[T]) Clone() iterator[T] { return &i }
 func (i staticIter[T]) Concat(iters ...iterator[T]) iterator[T] {
 	for index, iter := range iters {
 		if iter.Size() < 0 {
-			return (&supplyIter[T]{supply: func() (t T, ok bool) {
-				for iter.HasNext() {
-					return iter.Next(), true
-				}
-				return t, false
-			}}).Concat(iters[index:]...)
+			return newSupplyIter(supplierOf[T](&i), leftSize[T](&i)).Concat(iters[index:]...)
 		}
 
 		data := iter.Left()
@@ -115,64 +163,149 @@ func (i staticIter[T]) Concat(iters ...iterator[T]) iterator[T] {
 	return &i
 }
 
+// newSupplyIter return iterator pulling elements from supply lazily, size may be sizeUnknown or sizeInfinite
+func newSupplyIter[T any](supply types.Supplier[T], size int64) *supplyIter[T] {
+	return &supplyIter[T]{supply: supply, size: size}
+}
+
+// newGenIter return iterator pulling elements lazily from supplier built by gen,
+// unstarted clones build a new supplier so that the source can be iterated again
+func newGenIter[T any](gen func() types.Supplier[T], size int64) *supplyIter[T] {
+	return &supplyIter[T]{supply: gen(), gen: gen, size: size}
+}
+
+// newBoundIter return iterator like newGenIter, with supplier built by bind on stream context,
+// for sources blocking until stream is cancelled. bind also return function releasing resources of supplier,
+// which is called once consumer stops early, it may be nil.
+func newBoundIter[T any](bind func(context.Context) (types.Supplier[T], func()), size int64) *supplyIter[T] {
+	i := newGenIter(func() types.Supplier[T] {
+		supply, _ := bind(context.Background()) // unstarted supplier holds nothing
+		return supply
+	}, size)
+	i.bind = bind
+	return i
+}
+
+// lazyOf return iterator pulling elements from supply, which reads source of unknown or infinite size lazily,
+// closing the iterator closes source
+func lazyOf[T, R any](source iterator[T], supply types.Supplier[R]) *supplyIter[R] {
+	i := newSupplyIter(supply, source.Size())
+	i.stop = func() { closeIter(source) }
+	return i
+}
+
+// supplyIter 惰性迭代器
 type supplyIter[T any] struct {
-	mu       sync.RWMutex
+	mu       sync.Mutex
 	dead     bool
 	curIndex int64
+	size     int64
 	supply   types.Supplier[T]
+	gen      func() types.Supplier[T]
+	bind     func(context.Context) (types.Supplier[T], func())
+	stop     func() // release resources of supply, called once by close
+
+	peeked bool // next element is fetched by HasNext
+	next   T
 }
 
-func (s *supplyIter[T]) Size() int64     { return -1 }
-func (s *supplyIter[T]) Left() []T       { return nil }
+func (s *supplyIter[T]) Size() int64     { return s.size }
 func (s *supplyIter[T]) CurIndex() int64 { return s.curIndex }
+func (s *supplyIter[T]) Left() (results []T) {
+	for s.HasNext() {
+		results = append(results, s.Next())
+	}
+	return results
+}
 func (s *supplyIter[T]) HasNext() bool {
-	s.mu.RLock()
-	defer s.mu.RUnlock()
-	return !s.dead
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	return s.fetch()
+}
+func (s *supplyIter[T]) HasNextN(n int64) bool {
+	if s.size < 0 {
+		return true
+	}
+	return s.curIndex+n-1 < s.size
 }
-func (s *supplyIter[T]) HasNextN(int64) bool { return true }
-func (s *supplyIter[T]) Next() T             { return s.NextN(1) }
-func (s *supplyIter[T]) NextN(n int64) T {
-	s.curIndex += n
-	for i := 0; i < int(n)-1; i++ {
-		s.supply()
+func (s *supplyIter[T]) Next() T { return s.NextN(1) }
+func (s *supplyIter[T]) NextN(n int64) (t T) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for ; n > 0 && s.fetch(); n-- {
+		t, s.peeked = s.next, false
+		s.curIndex++
 	}
+	return t
+}
 
-	t, ok := s.supply()
+// fetch fetch next element from supply if not fetched yet, must be called with lock held
+func (s *supplyIter[T]) fetch() bool {
+	if !s.peeked && !s.dead {
+		if s.next, s.peeked = s.supply(); !s.peeked {
+			s.dead = true
+		}
+	}
+	return s.peeked
+}
 
+// close stop supply and release its resources
+func (s *supplyIter[T]) close() {
 	s.mu.Lock()
-	s.dead = !ok
-	s.mu.Unlock()
-
-	return t
+	defer s.mu.Unlock()
+	if s.dead, s.peeked = true, false; s.stop != nil {
+		s.stop()
+		s.stop = nil
+	}
 }
 func (s *supplyIter[T]) Clone() iterator[T] {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.gen != nil && s.curIndex == 0 && !s.peeked && !s.dead {
+		i := newGenIter(s.gen, s.size)
+		i.bind = s.bind
+		return i
+	}
 	return &supplyIter[T]{
 		curIndex: s.curIndex,
+		size:     s.size,
 		supply:   s.supply,
 	}
 }
+
+// bindContext rebuild supplier of unstarted bound iterator on ctx
+func (s *supplyIter[T]) bindContext(ctx context.Context) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	if s.bind != nil && s.curIndex == 0 && !s.peeked && !s.dead {
+		s.supply, s.stop = s.bind(ctx)
+	}
+}
 func (s *supplyIter[T]) Concat(iters ...iterator[T]) iterator[T] {
 	if len(iters) == 0 {
 		return s
 	}
 
-	supply := s.supply
+	size, suppliers := leftSize[T](s), []types.Supplier[T]{supplierOf[T](s)}
 	for _, iter := range iters {
-		supply = func() (t T, ok bool) {
-			if item, ok := supply(); ok { // return supply not finished
-				return item, true
-			}
-			for iter.HasNext() {
-				return iter.Next(), true
-			}
-			return t, false
+		switch left := leftSize(iter); {
+		case size == sizeInfinite || left == sizeInfinite:
+			size = sizeInfinite
+		case size < 0 || left < 0:
+			size = sizeUnknown
+		default:
+			size += left
 		}
+		suppliers = append(suppliers, supplierOf(iter))
 	}
-	return &supplyIter[T]{
-		curIndex: s.curIndex,
-		supply:   supply,
+	i := newSupplyIter(chainSupplier(suppliers...), size)
+	i.stop = func() {
+		closeIter[T](s)
+		for _, iter := range iters {
+			closeIter(iter)
+		}
 	}
+	return i
 }
 
 func wrapAny[T any](iter iterator[T]) iterator[any]   { return &anyIter[T]{iter} }
@@ -185,6 +318,7 @@ func (a *anyIter[T]) Left() []any {
 }
 func (a *anyIter[T]) Next() any           { return a.iterator.NextN(1) }
 func (a *anyIter[T]) NextN(n int64) any   { return a.iterator.NextN(n) }
+func (a *anyIter[T]) close()              { closeIter(a.iterator) }
 func (a anyIter[T]) Clone() iterator[any] { return &anyIter[T]{a.iterator.Clone()} }
 func (a anyIter[T]) Concat(iters ...iterator[any]) iterator[any] {
 	wrappedIters := make([]iterator[T], 0, len(iters))
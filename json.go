@@ -0,0 +1,264 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidJSONPath invalid JSON array path
+var ErrInvalidJSONPath = errors.New("invalid json path")
+
+// DecodeJSONLines create a new stream decoding one JSON value of T per line of r lazily, blank lines are ignored.
+// Malformed lines are handled by opts.Policy, gzip compressed input is decoded transparently.
+func DecodeJSONLines[T any](r io.Reader, opts ...DecodeOptions) Streamer[T] {
+	var opt DecodeOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	s := newStreamer[T](nil)
+	rec := recorderOf(s.ctx)
+	next, line := newScanner(r, bufio.ScanLines, opt.ScanOptions, rec), 0
+	s.source = newSupplyIter(func() (t T, ok bool) {
+		for {
+			b, ok := next()
+			if !ok {
+				return t, false
+			}
+			if line++; len(bytes.TrimSpace(b)) == 0 {
+				continue
+			}
+
+			var item T
+			if err := json.Unmarshal(b, &item); err != nil {
+				if opt.reject(rec, &LineError{Line: line, Text: string(b), Err: err}) {
+					return t, false
+				}
+				continue
+			}
+			return item, true
+		}
+	}, sizeUnknown)
+	return s
+}
+
+// encodeJSONLines write elements from next to w as JSON Lines
+func encodeJSONLines[T any](next func() (T, bool), w io.Writer) error {
+	bw := bufio.NewWriter(w)
+	enc := json.NewEncoder(bw)
+	for t, ok := next(); ok; t, ok = next() {
+		if err := enc.Encode(t); err != nil {
+			return err
+		}
+	}
+	return bw.Flush()
+}
+
+// JSONArrayOptions options for DecodeJSONArray
+type JSONArrayOptions struct {
+	// Path select the array to decode, like $.data.items[*] or $.pages[0].rows[*],
+	// it must end with [*], default $[*] means the top-level array
+	Path string
+}
+
+// DecodeJSONArray create a new stream decoding elements of a JSON array in r one at a time,
+// only one element is held in memory, values out of path are skipped token by token.
+// Gzip compressed input is decoded transparently, errors are reported by Err.
+func DecodeJSONArray[T any](r io.Reader, opts ...JSONArrayOptions) Streamer[T] {
+	var opt JSONArrayOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	s := newStreamer[T](nil)
+	rec := recorderOf(s.ctx)
+	path, err := parseJSONPath(opt.Path)
+	if err != nil {
+		rec.record(err)
+		s.source = newIterator[T](nil)
+		return s
+	}
+
+	var dec *json.Decoder
+	var closer io.Closer
+	var done bool
+	stop := func(err error) {
+		if done = true; err != nil {
+			rec.record(fmt.Errorf("decode json array: %w", err))
+		}
+		if closer != nil {
+			_ = closer.Close()
+		}
+	}
+	s.source = newSupplyIter(func() (t T, ok bool) {
+		if done {
+			return t, false
+		}
+		if dec == nil { // open reader and seek to the array lazily
+			in, c, err := decompress(r)
+			if err != nil {
+				stop(err)
+				return t, false
+			}
+			closer, dec = c, json.NewDecoder(in)
+			if err := seekJSONPath(dec, path); err != nil {
+				stop(err)
+				return t, false
+			}
+		}
+		if !dec.More() {
+			stop(nil)
+			return t, false
+		}
+		if err := dec.Decode(&t); err != nil {
+			stop(err)
+			return t, false
+		}
+		return t, true
+	}, sizeUnknown)
+	return s
+}
+
+// jsonPathSegment object key or array index, index is -1 for key
+type jsonPathSegment struct {
+	key   string
+	index int
+}
+
+// parseJSONPath parse path to segments before the trailing [*]
+func parseJSONPath(path string) ([]jsonPathSegment, error) {
+	if path == "" {
+		path = "$[*]"
+	}
+	rest, ok := strings.CutPrefix(path, "$")
+	if !ok {
+		return nil, fmt.Errorf("%w %q: must start with $", ErrInvalidJSONPath, path)
+	}
+	if rest, ok = strings.CutSuffix(rest, "[*]"); !ok {
+		return nil, fmt.Errorf("%w %q: must end with [*]", ErrInvalidJSONPath, path)
+	}
+
+	var segments []jsonPathSegment
+	for rest != "" {
+		switch rest[0] {
+		case '.':
+			end := strings.IndexAny(rest[1:], ".[") + 1
+			if end == 0 {
+				end = len(rest)
+			}
+			if end == 1 {
+				return nil, fmt.Errorf("%w %q: empty key", ErrInvalidJSONPath, path)
+			}
+			segments, rest = append(segments, jsonPathSegment{key: rest[1:end], index: -1}), rest[end:]
+		case '[':
+			end := strings.IndexByte(rest, ']')
+			if end < 0 {
+				return nil, fmt.Errorf("%w %q: unclosed [", ErrInvalidJSONPath, path)
+			}
+			index, err := strconv.Atoi(rest[1:end])
+			if err != nil || index < 0 {
+				return nil, fmt.Errorf("%w %q: only [*] at the end or non-negative index is supported", ErrInvalidJSONPath, path)
+			}
+			segments, rest = append(segments, jsonPathSegment{index: index}), rest[end+1:]
+		default:
+			return nil, fmt.Errorf("%w %q: unexpected %q", ErrInvalidJSONPath, path, rest[0])
+		}
+	}
+	return segments, nil
+}
+
+// seekJSONPath move dec into the array selected by path,
+// ErrInvalidJSONPath is reported if path does not resolve to an array
+func seekJSONPath(dec *json.Decoder, path []jsonPathSegment) (err error) {
+	at := "$"
+	for _, segment := range path {
+		if segment.index < 0 {
+			err = seekJSONKey(dec, at, segment.key)
+			at += "." + segment.key
+		} else {
+			err = seekJSONIndex(dec, at, segment.index)
+			at += "[" + strconv.Itoa(segment.index) + "]"
+		}
+		if err != nil {
+			return err
+		}
+	}
+	return expectJSONDelim(dec, at, '[')
+}
+
+func seekJSONKey(dec *json.Decoder, at, key string) error {
+	if err := expectJSONDelim(dec, at, '{'); err != nil {
+		return err
+	}
+	for dec.More() {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		if token == key {
+			return nil
+		}
+		if err = skipJSONValue(dec); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: key %q not found in %s", ErrInvalidJSONPath, key, at)
+}
+
+func seekJSONIndex(dec *json.Decoder, at string, index int) error {
+	if err := expectJSONDelim(dec, at, '['); err != nil {
+		return err
+	}
+	for i := index; dec.More(); i-- {
+		if i == 0 {
+			return nil
+		}
+		if err := skipJSONValue(dec); err != nil {
+			return err
+		}
+	}
+	return fmt.Errorf("%w: index %d out of range of %s", ErrInvalidJSONPath, index, at)
+}
+
+// expectJSONDelim read next token of value at, ErrInvalidJSONPath is reported if it is not delim
+func expectJSONDelim(dec *json.Decoder, at string, delim json.Delim) error {
+	token, err := dec.Token()
+	if err == io.EOF {
+		return io.ErrUnexpectedEOF
+	} else if err != nil {
+		return err
+	}
+	if token != delim {
+		kind := "array"
+		if delim == '{' {
+			kind = "object"
+		}
+		return fmt.Errorf("%w: %s is not an %s", ErrInvalidJSONPath, at, kind)
+	}
+	return nil
+}
+
+// skipJSONValue skip next value token by token without decoding it
+func skipJSONValue(dec *json.Decoder) error {
+	for depth := 0; ; {
+		token, err := dec.Token()
+		if err != nil {
+			return err
+		}
+		switch token {
+		case json.Delim('{'), json.Delim('['):
+			depth++
+		case json.Delim('}'), json.Delim(']'):
+			depth--
+		}
+		if depth == 0 {
+			return nil
+		}
+	}
+}
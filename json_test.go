@@ -0,0 +1,143 @@
+package stream_test
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"reflect"
+	"strconv"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+type event struct {
+	ID   int    `json:"id"`
+	Kind string `json:"kind"`
+}
+
+const eventLines = `{"id":1,"kind":"a"}
+{"id":2,"kind":"b"
+
+{"id":3,"kind":"c"}
+oops
+`
+
+func TestDecodeJSONLines(t *testing.T) {
+	s := stream.DecodeJSONLines[event](strings.NewReader(eventLines))
+	if result := s.ToSlice(); !reflect.DeepEqual(result, []event{{1, "a"}}) {
+		t.Errorf("fail: expect [{1 a}], got %v", result)
+	}
+	var lineErr *stream.LineError
+	if err := s.Err(); !errors.As(err, &lineErr) || lineErr.Line != 2 {
+		t.Errorf("fail: expect LineError of line 2, got %v", err)
+	}
+
+	result := stream.DecodeJSONLines[event](strings.NewReader(eventLines), stream.DecodeOptions{Policy: stream.ErrorSkip}).ToSlice()
+	if !reflect.DeepEqual(result, []event{{1, "a"}, {3, "c"}}) {
+		t.Errorf("skip: expect [{1 a} {3 c}], got %v", result)
+	}
+
+	var lines []int
+	s = stream.DecodeJSONLines[event](strings.NewReader(eventLines), stream.DecodeOptions{
+		Policy:     stream.ErrorDeadLetter,
+		DeadLetter: func(err *stream.LineError) { lines = append(lines, err.Line) },
+	})
+	if count := s.Count(); count != 2 || !reflect.DeepEqual(lines, []int{2, 5}) {
+		t.Errorf("dead letter: expect 2 elements and lines [2 5], got %d and %v", count, lines)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("dead letter: unexpected error: %v", err)
+	}
+}
+
+func TestEncodeJSONLines(t *testing.T) {
+	events := []event{{1, "a"}, {2, "b"}, {3, "c"}}
+
+	var buf bytes.Buffer
+	if err := stream.SliceOf(events...).EncodeJSONLines(&buf); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if result := stream.DecodeJSONLines[event](&buf).ToSlice(); !reflect.DeepEqual(result, events) {
+		t.Errorf("expect round trip %v, got %v", events, result)
+	}
+
+	buf.Reset()
+	if err := stream.SliceOf(events...).Parallel(2).EncodeJSONLines(&buf); err != nil || strings.Count(buf.String(), "\n") != 3 {
+		t.Errorf("async: expect 3 lines, got %q %v", buf.String(), err)
+	}
+
+	if err := stream.SliceOf[any](func() {}).EncodeJSONLines(&buf); err == nil {
+		t.Errorf("expect unsupported type error")
+	}
+}
+
+func TestDecodeJSONArray(t *testing.T) {
+	result := stream.DecodeJSONArray[event](strings.NewReader(`[{"id":1,"kind":"a"}, {"id":2,"kind":"b"}]`)).ToSlice()
+	if !reflect.DeepEqual(result, []event{{1, "a"}, {2, "b"}}) {
+		t.Errorf("expect [{1 a} {2 b}], got %v", result)
+	}
+
+	doc := `{"meta": {"items": [0], "note": "x"}, "data": {"total": 2, "items": [{"id":3,"kind":"c"}, {"id":4,"kind":"d"}]}, "tail": [1, 2]}`
+	s := stream.DecodeJSONArray[event](strings.NewReader(doc), stream.JSONArrayOptions{Path: "$.data.items[*]"})
+	if result := s.Map(func(e event) event { e.ID *= 10; return e }).ToSlice(); !reflect.DeepEqual(result, []event{{30, "c"}, {40, "d"}}) {
+		t.Errorf("path: expect [{30 c} {40 d}], got %v", result)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("path: unexpected error: %v", err)
+	}
+
+	if result := stream.DecodeJSONArray[int](strings.NewReader(`{"pages": [[1], [2, 3]]}`), stream.JSONArrayOptions{Path: "$.pages[1][*]"}).ToSlice(); !reflect.DeepEqual(result, []int{2, 3}) {
+		t.Errorf("index: expect [2 3], got %v", result)
+	}
+	for name, s := range map[string]stream.Streamer[int]{
+		"missing":   stream.DecodeJSONArray[int](strings.NewReader(doc), stream.JSONArrayOptions{Path: "$.missing[*]"}),
+		"object":    stream.DecodeJSONArray[int](strings.NewReader(doc), stream.JSONArrayOptions{Path: "$.data[*]"}),
+		"index":     stream.DecodeJSONArray[int](strings.NewReader(doc), stream.JSONArrayOptions{Path: "$.tail[5][*]"}),
+		"top-level": stream.DecodeJSONArray[int](strings.NewReader(`{"a":1}`)),
+	} {
+		if count := s.Count(); count != 0 || !errors.Is(s.Err(), stream.ErrInvalidJSONPath) {
+			t.Errorf("%s: expect ErrInvalidJSONPath, got %d %v", name, count, s.Err())
+		}
+	}
+
+	ints := stream.DecodeJSONArray[int](strings.NewReader(doc), stream.JSONArrayOptions{Path: "$.data.items"})
+	if count := ints.Count(); count != 0 || !errors.Is(ints.Err(), stream.ErrInvalidJSONPath) {
+		t.Errorf("invalid: expect ErrInvalidJSONPath, got %d %v", count, ints.Err())
+	}
+
+	ints = stream.DecodeJSONArray[int](strings.NewReader(`[1, 2, "x", 4]`))
+	if result := ints.ToSlice(); !reflect.DeepEqual(result, []int{1, 2}) || ints.Err() == nil {
+		t.Errorf("malformed: expect [1 2] and error, got %v %v", result, ints.Err())
+	}
+}
+
+func TestDecodeJSONArrayWithContext(t *testing.T) {
+	var doc strings.Builder
+	doc.WriteString("[0")
+	for i := 1; i < 100000; i++ {
+		doc.WriteString("," + strconv.Itoa(i))
+	}
+	doc.WriteString("]")
+
+	for name, parallel := range map[string]int{"sync": 0, "async": 2} {
+		ctx, cancel := context.WithCancel(context.Background())
+		s := stream.DecodeJSONArray[int](strings.NewReader(doc.String())).WithContext(ctx).Parallel(parallel)
+
+		var consumed atomic.Int64
+		s.ForEach(func(int) {
+			if consumed.Add(1) == 10 {
+				cancel()
+			}
+		})
+		if count := consumed.Load(); count < 10 || count >= 100000 {
+			t.Errorf("%s: expect decoding stopped once cancelled, got %d elements", name, count)
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("%s: unexpected error: %v", name, err)
+		}
+		cancel()
+	}
+}
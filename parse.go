@@ -0,0 +1,201 @@
+package stream
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// ErrNoMatch line does not match pattern
+var ErrNoMatch = errors.New("line does not match")
+
+// CombinedLogPattern pattern of Apache/Nginx combined log format, common log format matches as well
+const CombinedLogPattern = `^(?P<remote_addr>\S+) (?P<ident>\S+) (?P<user>\S+) \[(?P<time>[^\]]+)\] ` +
+	`"(?P<method>\S+) (?P<path>\S+) ?(?P<protocol>[^"]*)" (?P<status>\d{3}) (?:(?P<bytes>\d+)|-)` +
+	`(?: "(?P<referer>[^"]*)" "(?P<user_agent>[^"]*)")?`
+
+// CombinedLog line of Apache/Nginx combined log format
+type CombinedLog struct {
+	RemoteAddr string    `regex:"remote_addr"`
+	Ident      string    `regex:"ident"`
+	User       string    `regex:"user"`
+	Time       time.Time `regex:"time,layout=02/Jan/2006:15:04:05 -0700"`
+	Method     string    `regex:"method"`
+	Path       string    `regex:"path"`
+	Protocol   string    `regex:"protocol"`
+	Status     int       `regex:"status"`
+	Bytes      int64     `regex:"bytes"`
+	Referer    string    `regex:"referer"`
+	UserAgent  string    `regex:"user_agent"`
+}
+
+// ParseOptions options for line parsers
+type ParseOptions struct {
+	// TimeLayout layout of time.Time fields without layout tag option, default time.RFC3339
+	TimeLayout string
+
+	// Policy decide what to do with unmatched or malformed lines, default ErrorFail
+	Policy ErrorPolicy
+	// DeadLetter receive unmatched or malformed lines under ErrorDeadLetter policy
+	DeadLetter func(*LineError)
+}
+
+// ParseRegex create a new stream parsing lines of s by pattern to T, which is map[string]string of named groups,
+// or struct or pointer to struct whose fields are mapped to named groups by `regex:"name"` tag or field name.
+// Time fields may set layout like `regex:"time,layout=02/Jan/2006:15:04:05 -0700"`, groups not participating
+// in match leave fields zero. Unmatched lines are handled by opts.Policy with ErrNoMatch.
+func ParseRegex[T any](s Streamer[string], pattern string, opts ...ParseOptions) Streamer[T] {
+	var opt ParseOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.TimeLayout == "" {
+		opt.TimeLayout = time.RFC3339
+	}
+
+	re, err := regexp.Compile(pattern)
+	var decode func([]string) (T, error)
+	if err == nil {
+		decode, err = regexDecoder[T](re, opt.TimeLayout)
+	}
+	if err != nil {
+		return derive(s, func(_ func() (string, bool), rec *errRecorder) types.Supplier[T] {
+			rec.record(fmt.Errorf("parse regex: %w", err))
+			return func() (t T, ok bool) { return t, false }
+		})
+	}
+	return parseLines(s, opt, func(line string) (T, error) {
+		match := re.FindStringSubmatch(line)
+		if match == nil {
+			var t T
+			return t, ErrNoMatch
+		}
+		return decode(match)
+	})
+}
+
+// regexDecoder return function decoding submatches of re to T
+func regexDecoder[T any](re *regexp.Regexp, layout string) (func([]string) (T, error), error) {
+	names := re.SubexpNames()
+	if _, ok := any((*T)(nil)).(*map[string]string); ok {
+		return func(match []string) (t T, err error) {
+			groups := make(map[string]string, len(names))
+			for i, name := range names {
+				if name != "" && match[i] != "" {
+					groups[name] = match[i]
+				}
+			}
+			*any(&t).(*map[string]string) = groups
+			return t, nil
+		}, nil
+	}
+
+	typ, fields, err := fieldsOf[T]("regex")
+	if err != nil {
+		return nil, err
+	}
+	groups, layouts := make([]*textField, len(names)), make([]string, len(names))
+	for _, f := range fields {
+		name, fieldLayout, ok := strings.Cut(f.name, ",layout=")
+		if !ok {
+			fieldLayout = layout
+		}
+		if i := re.SubexpIndex(name); i > 0 {
+			groups[i], layouts[i] = f, fieldLayout
+		}
+	}
+	return func(match []string) (T, error) {
+		value := reflect.New(typ).Elem()
+		for i, f := range groups {
+			if f == nil || match[i] == "" {
+				continue
+			}
+			if err := f.parse(value, match[i], layouts[i]); err != nil {
+				var t T
+				return t, fmt.Errorf("group %s: %w", names[i], err)
+			}
+		}
+		return structElem[T](value), nil
+	}, nil
+}
+
+// ParseLogfmt create a new stream parsing logfmt lines of s like `level=info msg="hello world" ok`,
+// keys without value are mapped to empty string. Malformed lines are handled by opts.Policy.
+func ParseLogfmt(s Streamer[string], opts ...ParseOptions) Streamer[map[string]string] {
+	var opt ParseOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	return parseLines(s, opt, parseLogfmt)
+}
+
+// ParseCombinedLog create a new stream parsing Apache/Nginx combined log lines of s.
+// Unmatched lines are handled by opts.Policy with ErrNoMatch.
+func ParseCombinedLog(s Streamer[string], opts ...ParseOptions) Streamer[CombinedLog] {
+	return ParseRegex[CombinedLog](s, CombinedLogPattern, opts...)
+}
+
+// parseLines return stream of lines of s parsed by parse, failed lines are handled by opt.Policy
+func parseLines[T any](s Streamer[string], opt ParseOptions, parse func(string) (T, error)) Streamer[T] {
+	policy := DecodeOptions{Policy: opt.Policy, DeadLetter: opt.DeadLetter}
+	return derive(s, func(next func() (string, bool), rec *errRecorder) types.Supplier[T] {
+		var line int
+		return func() (t T, ok bool) {
+			for text, more := next(); more; text, more = next() {
+				line++
+				item, err := parse(text)
+				if err == nil {
+					return item, true
+				}
+				if policy.reject(rec, &LineError{Line: line, Text: text, Err: err}) {
+					break
+				}
+			}
+			return t, false
+		}
+	})
+}
+
+func parseLogfmt(line string) (map[string]string, error) {
+	fields := make(map[string]string)
+	for rest := strings.TrimSpace(line); rest != ""; rest = strings.TrimLeft(rest, " \t") {
+		end := strings.IndexAny(rest, "= \t")
+		if end < 0 {
+			end = len(rest)
+		}
+		key := rest[:end]
+		if key == "" {
+			return nil, fmt.Errorf("logfmt: empty key at %q", rest)
+		}
+		if rest = rest[end:]; !strings.HasPrefix(rest, "=") {
+			fields[key] = ""
+			continue
+		}
+
+		rest = rest[1:]
+		if !strings.HasPrefix(rest, `"`) {
+			end = strings.IndexAny(rest, " \t")
+			if end < 0 {
+				end = len(rest)
+			}
+			fields[key], rest = rest[:end], rest[end:]
+			continue
+		}
+
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("logfmt: bad quoted value of %s: %w", key, err)
+		}
+		if fields[key], err = strconv.Unquote(quoted); err != nil {
+			return nil, fmt.Errorf("logfmt: bad quoted value of %s: %w", key, err)
+		}
+		rest = rest[len(quoted):]
+	}
+	return fields, nil
+}
@@ -0,0 +1,75 @@
+package stream_test
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+type request struct {
+	Level   string `regex:"level"`
+	Latency int    `regex:"ms"`
+	Path    *string
+}
+
+func TestParseRegex(t *testing.T) {
+	lines := stream.SliceOf("INFO /a 12", "garbage", "WARN /b 7", "INFO /c x")
+	pattern := `^(?P<level>[A-Z]+) (?P<Path>\S+) (?P<ms>\S+)$`
+
+	s := stream.ParseRegex[request](lines, pattern)
+	if result := s.ToSlice(); len(result) != 1 || result[0].Level != "INFO" || result[0].Latency != 12 || *result[0].Path != "/a" {
+		t.Errorf("fail: expect first line only, got %+v", result)
+	}
+	var lineErr *stream.LineError
+	if err := s.Err(); !errors.As(err, &lineErr) || lineErr.Line != 2 || !errors.Is(err, stream.ErrNoMatch) {
+		t.Errorf("fail: expect ErrNoMatch of line 2, got %v", err)
+	}
+
+	var rejected []int
+	result := stream.ParseRegex[*request](lines, pattern, stream.ParseOptions{
+		Policy:     stream.ErrorDeadLetter,
+		DeadLetter: func(err *stream.LineError) { rejected = append(rejected, err.Line) },
+	}).ToSlice()
+	if len(result) != 2 || result[1].Latency != 7 || !reflect.DeepEqual(rejected, []int{2, 4}) {
+		t.Errorf("dead letter: expect 2 parsed and lines [2 4] rejected, got %d %v", len(result), rejected)
+	}
+
+	groups := stream.ParseRegex[map[string]string](lines.Parallel(2), pattern, stream.ParseOptions{Policy: stream.ErrorSkip}).Count()
+	if groups != 3 {
+		t.Errorf("map: expect 3 matched lines, got %d", groups)
+	}
+
+	invalid := stream.ParseRegex[request](stream.SliceOf("INFO /a 12"), `(`)
+	if count := invalid.Count(); count != 0 || invalid.Err() == nil {
+		t.Errorf("expect invalid pattern error, got %d %v", count, invalid.Err())
+	}
+}
+
+func TestParseLogfmt(t *testing.T) {
+	lines := stream.Lines(strings.NewReader("level=info msg=\"hello \\\"world\\\"\" ok dur=1s\nkey=\"unterminated\n=bad\n"))
+	s := stream.ParseLogfmt(lines, stream.ParseOptions{Policy: stream.ErrorSkip})
+	expect := []map[string]string{{"level": "info", "msg": `hello "world"`, "ok": "", "dur": "1s"}}
+	if result := s.ToSlice(); !reflect.DeepEqual(result, expect) {
+		t.Errorf("expect %v, got %v", expect, result)
+	}
+}
+
+func TestParseCombinedLog(t *testing.T) {
+	lines := stream.SliceOf(
+		`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326 "http://www.example.com/start.html" "Mozilla/4.08"`,
+		`10.0.0.2 - - [10/Oct/2000:13:55:37 -0700] "POST /login HTTP/1.1" 302 -`,
+	)
+	result := stream.ParseCombinedLog(lines).ToSlice()
+	if len(result) != 2 {
+		t.Fatalf("expect 2 lines, got %d", len(result))
+	}
+	if r := result[0]; r.User != "frank" || r.Status != 200 || r.Bytes != 2326 || r.UserAgent != "Mozilla/4.08" || r.Time.Minute() != 55 {
+		t.Errorf("unexpected first line %+v", r)
+	}
+	if r := result[1]; r.Method != "POST" || r.Status != 302 || r.Bytes != 0 || r.Referer != "" {
+		t.Errorf("unexpected second line %+v", r)
+	}
+}
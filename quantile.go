@@ -0,0 +1,175 @@
+package stream
+
+import (
+	"math"
+	"sort"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// defaultCompression default t-digest compression
+const defaultCompression = 100
+
+// Quantiles return exact quantiles qs (0-1) of stream with linear interpolation,
+// all elements are kept in memory so stream must be finite, NaN is returned for empty stream
+func Quantiles[T types.Number](s Streamer[T], qs ...float64) []float64 {
+	data := fold(s, func() []float64 { return nil },
+		func(data []float64, t T) []float64 { return append(data, float64(t)) },
+		func(data, other []float64) []float64 { return append(data, other...) },
+	)
+	sort.Float64s(data)
+
+	results := make([]float64, len(qs))
+	for i, q := range qs {
+		results[i] = exactQuantile(data, q)
+	}
+	return results
+}
+
+// Percentile return exact percentile p (0-100) of stream
+func Percentile[T types.Number](s Streamer[T], p float64) float64 { return Quantiles(s, p/100)[0] }
+
+// QuantilesApprox return approximate quantiles qs (0-1) of stream with t-digest in bounded memory,
+// larger compression is more accurate and use more memory, 0 means 100.
+// Parallel workers build their own digests which are merged at last.
+func QuantilesApprox[T types.Number](s Streamer[T], compression float64, qs ...float64) []float64 {
+	digest := fold(s, func() *TDigest { return NewTDigest(compression) },
+		func(d *TDigest, t T) *TDigest { d.Add(float64(t)); return d },
+		func(d, other *TDigest) *TDigest { d.Merge(other); return d },
+	)
+
+	results := make([]float64, len(qs))
+	for i, q := range qs {
+		results[i] = digest.Quantile(q)
+	}
+	return results
+}
+
+// PercentileApprox return approximate percentile p (0-100) of stream with t-digest
+func PercentileApprox[T types.Number](s Streamer[T], compression, p float64) float64 {
+	return QuantilesApprox(s, compression, p/100)[0]
+}
+
+// exactQuantile return quantile q of sorted data
+func exactQuantile(sorted []float64, q float64) float64 {
+	switch {
+	case len(sorted) == 0:
+		return math.NaN()
+	case q <= 0:
+		return sorted[0]
+	case q >= 1:
+		return sorted[len(sorted)-1]
+	}
+
+	pos := q * float64(len(sorted)-1)
+	index := int(pos)
+	if index+1 >= len(sorted) {
+		return sorted[index]
+	}
+	return sorted[index] + (pos-float64(index))*(sorted[index+1]-sorted[index])
+}
+
+// TDigest t-digest quantile estimator, not safe for concurrent use
+type TDigest struct {
+	compression float64
+	centroids   []centroid
+	buffer      []centroid
+	count       float64
+	min, max    float64
+}
+
+type centroid struct{ mean, count float64 }
+
+// NewTDigest return t-digest, compression 0 means 100
+func NewTDigest(compression float64) *TDigest {
+	if compression <= 0 {
+		compression = defaultCompression
+	}
+	return &TDigest{compression: compression, min: math.Inf(1), max: math.Inf(-1)}
+}
+
+// Count return number of values added
+func (d *TDigest) Count() float64 { return d.count }
+
+// Add add value
+func (d *TDigest) Add(x float64) { d.add(centroid{mean: x, count: 1}) }
+
+func (d *TDigest) add(c centroid) {
+	if math.IsNaN(c.mean) || c.count <= 0 {
+		return
+	}
+
+	d.buffer = append(d.buffer, c)
+	d.count += c.count
+	d.min, d.max = math.Min(d.min, c.mean), math.Max(d.max, c.mean)
+	if len(d.buffer) >= int(5*d.compression) {
+		d.compress()
+	}
+}
+
+// Merge merge other into d
+func (d *TDigest) Merge(other *TDigest) {
+	for _, c := range other.centroids {
+		d.add(c)
+	}
+	for _, c := range other.buffer {
+		d.add(c)
+	}
+}
+
+// Quantile return estimated quantile q (0-1), NaN if no value added
+func (d *TDigest) Quantile(q float64) float64 {
+	d.compress()
+	switch {
+	case d.count == 0:
+		return math.NaN()
+	case q <= 0:
+		return d.min
+	case q >= 1:
+		return d.max
+	}
+
+	target, cum := q*d.count, 0.0
+	for i, c := range d.centroids {
+		center := cum + c.count/2
+		if target < center {
+			if i == 0 { // between min and the first centroid
+				return d.min + (c.mean-d.min)*target/center
+			}
+			prev := d.centroids[i-1]
+			prevCenter := cum - prev.count/2
+			return prev.mean + (c.mean-prev.mean)*(target-prevCenter)/(center-prevCenter)
+		}
+		cum += c.count
+	}
+
+	// between the last centroid and max
+	last := d.centroids[len(d.centroids)-1]
+	lastCenter := d.count - last.count/2
+	return last.mean + (d.max-last.mean)*(target-lastCenter)/(d.count-lastCenter)
+}
+
+// compress merge buffered values into centroids, each centroid is limited to 4*count*q*(1-q)/compression
+func (d *TDigest) compress() {
+	if len(d.buffer) == 0 {
+		return
+	}
+
+	all := append(d.centroids, d.buffer...)
+	sort.Slice(all, func(i, j int) bool { return all[i].mean < all[j].mean })
+
+	merged, cum := make([]centroid, 0, len(d.centroids)+1), 0.0
+	current := all[0]
+	for _, c := range all[1:] {
+		q := (cum + current.count + c.count/2) / d.count
+		if current.count+c.count <= 4*d.count*q*(1-q)/d.compression {
+			current.count += c.count
+			current.mean += (c.mean - current.mean) * c.count / current.count
+			continue
+		}
+		merged = append(merged, current)
+		cum += current.count
+		current = c
+	}
+	d.centroids, d.buffer = append(merged, current), d.buffer[:0]
+}
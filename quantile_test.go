@@ -0,0 +1,34 @@
+package stream_test
+
+import (
+	"math"
+	"math/rand"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestQuantiles(t *testing.T) {
+	if result := stream.Quantiles(stream.SliceOf(3, 1, 4, 2, 5), 0, 0.5, 0.25, 1); result[0] != 1 || result[1] != 3 || result[2] != 2 || result[3] != 5 {
+		t.Errorf("expect [1 3 2 5], got %v", result)
+	}
+	if p := stream.Percentile(stream.SliceOf(1.0, 2.0), 50); p != 1.5 {
+		t.Errorf("expect 1.5, got %v", p)
+	}
+	if p := stream.Percentile(stream.SliceOf[int](), 50); !math.IsNaN(p) {
+		t.Errorf("expect NaN for empty stream, got %v", p)
+	}
+
+	r := rand.New(rand.NewSource(1))
+	data := make([]float64, 100000)
+	for i := range data {
+		data[i] = r.Float64() * 1000
+	}
+	exact := stream.Quantiles(stream.SliceOf(data...), 0.5, 0.95, 0.99)
+	approx := stream.QuantilesApprox(stream.SliceOf(data...).Parallel(4), 100, 0.5, 0.95, 0.99)
+	for i := range exact {
+		if math.Abs(exact[i]-approx[i]) > 5 {
+			t.Errorf("expect approximate quantile close to %v, got %v", exact[i], approx[i])
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package stream
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"fmt"
+	"io"
+)
+
+// gzipMagic leading bytes of gzip stream
+var gzipMagic = []byte{0x1f, 0x8b}
+
+// ScanOptions options for Lines and Scan
+type ScanOptions struct {
+	// MaxTokenSize max size of one token, default bufio.MaxScanTokenSize,
+	// longer token stop the stream with bufio.ErrTooLong
+	MaxTokenSize int
+}
+
+func (o ScanOptions) withDefault() ScanOptions {
+	if o.MaxTokenSize <= 0 {
+		o.MaxTokenSize = bufio.MaxScanTokenSize
+	}
+	return o
+}
+
+// Lines create a new stream of lines read from r lazily, line endings are stripped.
+// Gzip compressed input is decoded transparently, read errors are reported by Err.
+func Lines(r io.Reader, opts ...ScanOptions) Streamer[string] {
+	return scanOf(r, bufio.ScanLines, func(token []byte) string { return string(token) }, opts...)
+}
+
+// Scan create a new stream of tokens read from r lazily and split by split, tokens are copied.
+// Gzip compressed input is decoded transparently, read errors are reported by Err.
+func Scan(r io.Reader, split bufio.SplitFunc, opts ...ScanOptions) Streamer[[]byte] {
+	return scanOf(r, split, func(token []byte) []byte { return append([]byte(nil), token...) }, opts...)
+}
+
+func scanOf[T any](r io.Reader, split bufio.SplitFunc, token func([]byte) T, opts ...ScanOptions) Streamer[T] {
+	var opt ScanOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+
+	s := newStreamer[T](nil)
+	next := newScanner(r, split, opt, recorderOf(s.ctx))
+	s.source = newSupplyIter(func() (t T, ok bool) {
+		b, ok := next()
+		if ok {
+			t = token(b)
+		}
+		return t, ok
+	}, sizeUnknown)
+	return s
+}
+
+// newScanner return function scanning tokens from r lazily, token is only valid until next call.
+// Errors are recorded to rec and stop scanning.
+func newScanner(r io.Reader, split bufio.SplitFunc, opt ScanOptions, rec *errRecorder) func() ([]byte, bool) {
+	opt = opt.withDefault()
+
+	var scanner *bufio.Scanner
+	var closer io.Closer
+	var done bool
+	return func() ([]byte, bool) {
+		if done {
+			return nil, false
+		}
+		if scanner == nil { // open reader lazily, so that nothing is read before stream runs
+			in, c, err := decompress(r)
+			if err != nil {
+				done = true
+				rec.record(fmt.Errorf("open reader: %w", err))
+				return nil, false
+			}
+			closer, scanner = c, bufio.NewScanner(in)
+			scanner.Buffer(make([]byte, 0, min(opt.MaxTokenSize, 64*1024)), opt.MaxTokenSize)
+			scanner.Split(split)
+		}
+		if scanner.Scan() {
+			return scanner.Bytes(), true
+		}
+
+		done = true
+		if err := scanner.Err(); err != nil {
+			rec.record(fmt.Errorf("scan reader: %w", err))
+		}
+		if closer != nil {
+			_ = closer.Close()
+		}
+		return nil, false
+	}
+}
+
+// decompress return reader decoding r if r is gzip compressed, closer is nil if r is not compressed
+func decompress(r io.Reader) (io.Reader, io.Closer, error) {
+	br := bufio.NewReader(r)
+	if magic, err := br.Peek(len(gzipMagic)); err != nil || !bytes.Equal(magic, gzipMagic) {
+		return br, nil, nil
+	}
+	zr, err := gzip.NewReader(br)
+	if err != nil {
+		return nil, nil, err
+	}
+	return zr, zr, nil
+}
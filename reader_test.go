@@ -0,0 +1,53 @@
+package stream_test
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestLines(t *testing.T) {
+	text := "alpha\nbeta\r\n\ngamma"
+	expect := []string{"alpha", "beta", "", "gamma"}
+	if result := stream.Lines(strings.NewReader(text)).ToSlice(); !reflect.DeepEqual(result, expect) {
+		t.Errorf("expect %q, got %q", expect, result)
+	}
+
+	var buf bytes.Buffer
+	zw := gzip.NewWriter(&buf)
+	_, _ = zw.Write([]byte(text))
+	_ = zw.Close()
+	s := stream.Lines(&buf)
+	if result := s.ToSlice(); !reflect.DeepEqual(result, expect) {
+		t.Errorf("gzip: expect %q, got %q", expect, result)
+	}
+	if err := s.Err(); err != nil {
+		t.Errorf("gzip: unexpected error: %v", err)
+	}
+
+	s = stream.Lines(strings.NewReader("short\n"+strings.Repeat("x", 100)+"\nnext"), stream.ScanOptions{MaxTokenSize: 16})
+	if result := s.ToSlice(); !reflect.DeepEqual(result, []string{"short"}) {
+		t.Errorf("expect [short], got %q", result)
+	}
+	if err := s.Err(); !errors.Is(err, bufio.ErrTooLong) {
+		t.Errorf("expect ErrTooLong, got %v", err)
+	}
+}
+
+func TestScan(t *testing.T) {
+	words := stream.Scan(strings.NewReader("to be  or\nnot to be"), bufio.ScanWords).ToSlice()
+	if len(words) != 6 || string(words[0]) != "to" || string(words[5]) != "be" {
+		t.Errorf("expect 6 words, got %q", words)
+	}
+
+	count := stream.Scan(strings.NewReader("a b c d"), bufio.ScanWords).Filter(func(b []byte) bool { return b[0] != 'c' }).Count()
+	if count != 3 {
+		t.Errorf("expect 3 words, got %d", count)
+	}
+}
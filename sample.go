@@ -0,0 +1,76 @@
+package stream
+
+import (
+	"container/heap"
+	"math"
+	"math/rand"
+)
+
+// reservoirSample sample k elements uniformly from next in one pass with O(k) memory, algorithm R
+func reservoirSample[T any](next func() (T, bool), k int, r *rand.Rand) []T {
+	if k <= 0 {
+		return nil
+	}
+
+	reservoir := make([]T, 0, k)
+	for seen := int64(0); ; seen++ {
+		t, ok := next()
+		if !ok {
+			return reservoir
+		}
+		if seen < int64(k) {
+			reservoir = append(reservoir, t)
+		} else if j := r.Int63n(seen + 1); j < int64(k) {
+			reservoir[j] = t
+		}
+	}
+}
+
+// weightedSample sample k elements with probability proportional to weight in one pass,
+// algorithm A-Res by Efraimidis and Spirakis, elements with non-positive weight are never chosen
+func weightedSample[T any](next func() (T, bool), k int, weight func(T) float64, r *rand.Rand) []T {
+	if k <= 0 {
+		return nil
+	}
+
+	h := &weightedHeap[T]{}
+	for t, ok := next(); ok; t, ok = next() {
+		w := weight(t)
+		if w <= 0 {
+			continue
+		}
+
+		key := math.Pow(r.Float64(), 1/w)
+		if h.Len() < k {
+			heap.Push(h, weightedItem[T]{item: t, key: key})
+		} else if key > (*h)[0].key {
+			(*h)[0] = weightedItem[T]{item: t, key: key}
+			heap.Fix(h, 0)
+		}
+	}
+
+	results := make([]T, h.Len())
+	for i := len(results) - 1; i >= 0; i-- {
+		results[i] = heap.Pop(h).(weightedItem[T]).item
+	}
+	return results
+}
+
+type weightedItem[T any] struct {
+	item T
+	key  float64
+}
+
+// weightedHeap min heap of weighted items by key
+type weightedHeap[T any] []weightedItem[T]
+
+func (h weightedHeap[T]) Len() int           { return len(h) }
+func (h weightedHeap[T]) Less(i, j int) bool { return h[i].key < h[j].key }
+func (h weightedHeap[T]) Swap(i, j int)      { h[i], h[j] = h[j], h[i] }
+func (h *weightedHeap[T]) Push(x any)        { *h = append(*h, x.(weightedItem[T])) }
+func (h *weightedHeap[T]) Pop() any {
+	old := *h
+	item := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return item
+}
@@ -0,0 +1,51 @@
+package stream_test
+
+import (
+	"math/rand"
+	"reflect"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestSample(t *testing.T) {
+	counter := func(n int) func() (int, bool) {
+		var i int
+		return func() (int, bool) { i++; return i, i <= n }
+	}
+
+	first := stream.Of(counter(1000)).WithRand(rand.NewSource(42)).Sample(10)
+	second := stream.Of(counter(1000)).WithRand(rand.NewSource(42)).Sample(10)
+	if len(first) != 10 || !reflect.DeepEqual(first, second) {
+		t.Errorf("expect reproducible 10 samples, got %v and %v", first, second)
+	}
+
+	if result := stream.SliceOf(1, 2, 3).Parallel(2).Sample(5); len(result) != 3 {
+		t.Errorf("expect all 3 elements, got %v", result)
+	}
+
+	weighted := stream.SliceOf(1, 2, 3, 4, 5, 6).WithRand(rand.NewSource(7)).
+		SampleWeighted(3, func(i int) float64 { return float64(i % 2) })
+	if !reflect.DeepEqual(stream.SliceOf(weighted...).Sort(func(l, r int) int { return l - r }).ToSlice(), []int{1, 3, 5}) {
+		t.Errorf("expect odd elements only, got %v", weighted)
+	}
+
+	if count := stream.Of(counter(10000)).WithRand(rand.NewSource(1)).SampleRate(0.1).Count(); count < 800 || count > 1200 {
+		t.Errorf("expect about 1000 elements, got %d", count)
+	}
+}
+
+// run with -race to detect data race of sampling under Parallel
+func TestSampleParallel(t *testing.T) {
+	data := make([]int, 10000)
+	for i := range data {
+		data[i] = i
+	}
+
+	if result := stream.SliceOf(data...).Parallel(8).WithRand(rand.NewSource(3)).Sample(10); len(result) != 10 {
+		t.Errorf("Sample: expect 10 elements, got %v", result)
+	}
+	if count := stream.SliceOf(data...).Parallel(8).WithRand(rand.NewSource(3)).SampleRate(0.1).Count(); count < 800 || count > 1200 {
+		t.Errorf("SampleRate: expect about 1000 elements, got %d", count)
+	}
+}
@@ -0,0 +1,239 @@
+package stream
+
+import (
+	"bufio"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"sync"
+	"time"
+)
+
+var _ io.WriteCloser = new(RollingFile)
+
+// flushWriter writer buffering by itself
+type flushWriter interface {
+	io.Writer
+	Flush() error
+}
+
+// WriteTo write elements of s formatted by format to w serially in stream order, return bytes written.
+// Nil format write each element by fmt.Appendln. Writes are buffered unless w has Flush method, which is called at the end.
+func WriteTo[T any](s Streamer[T], w io.Writer, format func(T) []byte) (n int64, err error) {
+	if format == nil {
+		format = func(t T) []byte { return fmt.Appendln(nil, t) }
+	}
+	bw, ok := w.(flushWriter)
+	if !ok {
+		bw = bufio.NewWriter(w)
+	}
+
+	next, stop := pullAll(s)
+	defer stop()
+	for t, ok := next(); ok; t, ok = next() {
+		m, err := bw.Write(format(t))
+		if n += int64(m); err != nil {
+			return n, err
+		}
+	}
+	if err = bw.Flush(); err != nil {
+		return n, err
+	}
+	return n, s.Err()
+}
+
+// RollingFileSink write elements of s formatted by format to rolling files in dir, see WriteTo and RollingFile.
+// Files are flushed and closed when s is finished or cancelled.
+func RollingFileSink[T any](s Streamer[T], dir string, format func(T) []byte, opts ...RollingOptions) (err error) {
+	var opt RollingOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	rf, err := NewRollingFile(dir, opt)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if closeErr := rf.Close(); err == nil {
+			err = closeErr
+		}
+	}()
+	_, err = WriteTo(s, rf, format)
+	return err
+}
+
+// RollingOptions options for RollingFile, zero limits mean no limit
+type RollingOptions struct {
+	// Prefix file name prefix, default "segment"
+	Prefix string
+	// Ext file name extension, default ".log"
+	Ext string
+	// MaxBytes rotate before a write makes segment larger than MaxBytes
+	MaxBytes int64
+	// MaxCount rotate after MaxCount writes
+	MaxCount int
+	// MaxAge rotate before a write when segment is older than MaxAge
+	MaxAge time.Duration
+	// Compress gzip closed segments to name.gz
+	Compress bool
+	// Clock time source of MaxAge, default system clock
+	Clock Clock
+}
+
+func (o RollingOptions) withDefault() RollingOptions {
+	if o.Prefix == "" {
+		o.Prefix = "segment"
+	}
+	if o.Ext == "" {
+		o.Ext = ".log"
+	}
+	if o.Clock == nil {
+		o.Clock = systemClock{}
+	}
+	return o
+}
+
+// RollingFile writer rotating segment files in a directory, each Write is kept in one segment.
+// It is safe for concurrent use.
+type RollingFile struct {
+	mu sync.Mutex
+
+	dir  string
+	opts RollingOptions
+
+	seq     int
+	file    *os.File
+	buf     *bufio.Writer
+	bytes   int64
+	count   int
+	started time.Time
+	closed  bool
+}
+
+// NewRollingFile return RollingFile writing segments in dir, dir is created if not exists.
+// Segment is created on first write.
+func NewRollingFile(dir string, opts RollingOptions) (*RollingFile, error) {
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return nil, err
+	}
+	return &RollingFile{dir: dir, opts: opts.withDefault()}, nil
+}
+
+// Write write p into current segment, rotate before write if segment reaches limits
+func (r *RollingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.closed {
+		return 0, fs.ErrClosed
+	}
+	if r.file != nil && r.full(len(p)) {
+		if err := r.closeSegment(); err != nil {
+			return 0, err
+		}
+	}
+	if r.file == nil {
+		if err := r.openSegment(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err := r.buf.Write(p)
+	r.bytes += int64(n)
+	r.count++
+	return n, err
+}
+
+// Flush flush buffered data to current segment
+func (r *RollingFile) Flush() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.buf == nil {
+		return nil
+	}
+	return r.buf.Flush()
+}
+
+// Close flush and close current segment, it is safe to call Close many times
+func (r *RollingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.closed {
+		return nil
+	}
+	r.closed = true
+	return r.closeSegment()
+}
+
+// full report whether current segment should be rotated before writing n bytes
+func (r *RollingFile) full(n int) bool {
+	return (r.opts.MaxBytes > 0 && r.bytes > 0 && r.bytes+int64(n) > r.opts.MaxBytes) ||
+		(r.opts.MaxCount > 0 && r.count >= r.opts.MaxCount) ||
+		(r.opts.MaxAge > 0 && r.opts.Clock.Now().Sub(r.started) >= r.opts.MaxAge)
+}
+
+func (r *RollingFile) openSegment() error {
+	for {
+		r.seq++
+		name := filepath.Join(r.dir, fmt.Sprintf("%s-%06d%s", r.opts.Prefix, r.seq, r.opts.Ext))
+		if _, err := os.Stat(name + ".gz"); err == nil { // compressed segment of previous run
+			continue
+		}
+		f, err := os.OpenFile(name, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+		if errors.Is(err, fs.ErrExist) {
+			continue
+		} else if err != nil {
+			return err
+		}
+		r.file, r.buf = f, bufio.NewWriter(f)
+		r.bytes, r.count, r.started = 0, 0, r.opts.Clock.Now()
+		return nil
+	}
+}
+
+func (r *RollingFile) closeSegment() error {
+	if r.file == nil {
+		return nil
+	}
+	f := r.file
+	r.file = nil
+
+	err := r.buf.Flush()
+	if closeErr := f.Close(); err == nil {
+		err = closeErr
+	}
+	if err == nil && r.opts.Compress {
+		err = gzipFile(f.Name())
+	}
+	return err
+}
+
+// gzipFile compress file at path to path.gz and remove it
+func gzipFile(path string) (err error) {
+	src, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+".gz", os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(dst)
+	if _, err = io.Copy(zw, src); err == nil {
+		err = zw.Close()
+	}
+	if closeErr := dst.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		_ = os.Remove(path + ".gz")
+		return err
+	}
+	return os.Remove(path)
+}
@@ -0,0 +1,110 @@
+package stream_test
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"io"
+	"os"
+	"path/filepath"
+	"reflect"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/stream"
+)
+
+// stepClock advance step on each Now
+type stepClock struct {
+	now  time.Time
+	step time.Duration
+}
+
+func (c *stepClock) Now() time.Time { c.now = c.now.Add(c.step); return c.now }
+
+func readSegments(t *testing.T, dir string) (segments []string) {
+	entries, _ := os.ReadDir(dir)
+	for _, e := range entries {
+		data, err := os.ReadFile(filepath.Join(dir, e.Name()))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if strings.HasSuffix(e.Name(), ".gz") {
+			zr, err := gzip.NewReader(bytes.NewReader(data))
+			if err != nil {
+				t.Fatal(err)
+			}
+			data, _ = io.ReadAll(zr)
+		}
+		segments = append(segments, e.Name()+":"+strings.ReplaceAll(string(data), "\n", ","))
+	}
+	return segments
+}
+
+func TestWriteTo(t *testing.T) {
+	var buf bytes.Buffer
+	n, err := stream.WriteTo(stream.Range(0, 3, 1), &buf, nil)
+	if err != nil || n != 6 || buf.String() != "0\n1\n2\n" {
+		t.Errorf("expect 3 lines, got %d %q %v", n, buf.String(), err)
+	}
+
+	buf.Reset()
+	format := func(i int) []byte { return []byte(strings.Repeat(strconv.Itoa(i%10), 8) + "\n") }
+	if _, err = stream.WriteTo(stream.Range(0, 1000, 1).Parallel(8).Map(func(i int) int { return i }), &buf, format); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	for _, line := range strings.Split(strings.TrimSuffix(buf.String(), "\n"), "\n") {
+		if len(line) != 8 || strings.Count(line, line[:1]) != 8 {
+			t.Errorf("expect whole lines written serially, got %q", line)
+			break
+		}
+	}
+}
+
+func TestRollingFileSink(t *testing.T) {
+	format := func(i int) []byte { return []byte(strconv.Itoa(i) + "\n") }
+
+	dir := t.TempDir()
+	if err := stream.RollingFileSink(stream.Range(0, 5, 1), dir, format, stream.RollingOptions{MaxCount: 2, Compress: true}); err != nil {
+		t.Errorf("count: unexpected error: %v", err)
+	}
+	expect := []string{"segment-000001.log.gz:0,1,", "segment-000002.log.gz:2,3,", "segment-000003.log.gz:4,"}
+	if result := readSegments(t, dir); !reflect.DeepEqual(result, expect) {
+		t.Errorf("count: expect %v, got %v", expect, result)
+	}
+
+	dir = t.TempDir()
+	_ = stream.RollingFileSink(stream.Range(8, 13, 1), dir, format, stream.RollingOptions{Prefix: "size", MaxBytes: 6})
+	expect = []string{"size-000001.log:8,9,", "size-000002.log:10,11,", "size-000003.log:12,"}
+	if result := readSegments(t, dir); !reflect.DeepEqual(result, expect) {
+		t.Errorf("size: expect %v, got %v", expect, result)
+	}
+
+	dir = t.TempDir()
+	clock := &stepClock{step: time.Minute}
+	_ = stream.RollingFileSink(stream.Range(0, 4, 1), dir, format, stream.RollingOptions{MaxAge: 150 * time.Second, Clock: clock})
+	expect = []string{"segment-000001.log:0,1,2,", "segment-000002.log:3,"}
+	if result := readSegments(t, dir); !reflect.DeepEqual(result, expect) {
+		t.Errorf("age: expect %v, got %v", expect, result)
+	}
+}
+
+func TestRollingFileSinkCancel(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+	var i int
+	s := stream.Of(func() (int, bool) {
+		if i++; i == 4 {
+			cancel()
+		}
+		return i, true
+	}).WithContext(ctx)
+	if err := stream.RollingFileSink(s, dir, nil, stream.RollingOptions{Compress: true}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if result := readSegments(t, dir); len(result) != 1 || result[0] != "segment-000001.log.gz:1,2,3,4," {
+		t.Errorf("expect one flushed and compressed segment, got %v", result)
+	}
+}
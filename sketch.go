@@ -0,0 +1,268 @@
+package stream
+
+import (
+	"container/heap"
+	"math"
+	"math/bits"
+	"sort"
+)
+
+// CountDistinctApprox estimate distinct count of keys with HyperLogLog of precision (4-18),
+// relative error is about 1.04/sqrt(2^precision). Keys are extracted by key.
+func CountDistinctApprox[T any, K comparable](s Streamer[T], precision uint8, key func(T) K) uint64 {
+	return fold(s, func() *HyperLogLog { return NewHyperLogLog(precision) },
+		func(h *HyperLogLog, t T) *HyperLogLog { h.Add(key(t)); return h },
+		func(h, other *HyperLogLog) *HyperLogLog { _ = h.Merge(other); return h },
+	).Estimate()
+}
+
+// FrequencyEstimator build Count-Min sketch of keys, estimates exceed true frequency
+// by at most epsilon*total with probability 1-delta. Keys are extracted by key.
+func FrequencyEstimator[T any, K comparable](s Streamer[T], epsilon, delta float64, key func(T) K) *CountMinSketch {
+	return fold(s, func() *CountMinSketch { return NewCountMinSketch(epsilon, delta) },
+		func(c *CountMinSketch, t T) *CountMinSketch { c.Add(key(t), 1); return c },
+		func(c, other *CountMinSketch) *CountMinSketch { _ = c.Merge(other); return c },
+	)
+}
+
+// HeavyHitters return the approximately k most frequent keys with Space-Saving, in descending count order.
+// Keys are extracted by key.
+func HeavyHitters[T any, K comparable](s Streamer[T], k int, key func(T) K) []Frequency[T] {
+	return fold(s, func() *SpaceSaving[T, K] { return NewSpaceSaving[T, K](k) },
+		func(ss *SpaceSaving[T, K], t T) *SpaceSaving[T, K] { ss.Add(key(t), t); return ss },
+		func(ss, other *SpaceSaving[T, K]) *SpaceSaving[T, K] { ss.Merge(other); return ss },
+	).Top()
+}
+
+// HyperLogLog cardinality estimator, not safe for concurrent use
+type HyperLogLog struct {
+	precision uint8
+	registers []uint8
+}
+
+// NewHyperLogLog return HyperLogLog with 2^precision registers, precision is limited to 4-18
+func NewHyperLogLog(precision uint8) *HyperLogLog {
+	if precision < 4 {
+		precision = 4
+	} else if precision > 18 {
+		precision = 18
+	}
+	return &HyperLogLog{precision: precision, registers: make([]uint8, 1<<precision)}
+}
+
+// Add add key
+func (h *HyperLogLog) Add(key any) { h.AddHash(hashKey(key)) }
+
+// AddHash add 64-bit hash of key
+func (h *HyperLogLog) AddHash(hash uint64) {
+	index := hash >> (64 - h.precision)
+	rank := uint8(bits.LeadingZeros64(hash<<h.precision|1<<(h.precision-1))) + 1
+	if rank > h.registers[index] {
+		h.registers[index] = rank
+	}
+}
+
+// Merge merge other into h, both must have the same precision
+func (h *HyperLogLog) Merge(other *HyperLogLog) error {
+	if h.precision != other.precision {
+		return ErrSketchMismatch
+	}
+	for i, r := range other.registers {
+		if r > h.registers[i] {
+			h.registers[i] = r
+		}
+	}
+	return nil
+}
+
+// Estimate return estimated distinct count
+func (h *HyperLogLog) Estimate() uint64 {
+	m := float64(len(h.registers))
+
+	var sum float64
+	var zeros int
+	for _, r := range h.registers {
+		sum += math.Ldexp(1, -int(r))
+		if r == 0 {
+			zeros++
+		}
+	}
+
+	estimate := hllAlpha(m) * m * m / sum
+	if estimate <= 2.5*m && zeros > 0 { // small range correction with linear counting
+		estimate = m * math.Log(m/float64(zeros))
+	}
+	return uint64(estimate + 0.5)
+}
+
+func hllAlpha(m float64) float64 {
+	switch m {
+	case 16:
+		return 0.673
+	case 32:
+		return 0.697
+	case 64:
+		return 0.709
+	default:
+		return 0.7213 / (1 + 1.079/m)
+	}
+}
+
+// CountMinSketch frequency estimator, not safe for concurrent use
+type CountMinSketch struct {
+	width  uint64
+	counts [][]uint64
+	total  uint64
+}
+
+// NewCountMinSketch return Count-Min sketch, estimates exceed true frequency
+// by at most epsilon*total with probability 1-delta
+func NewCountMinSketch(epsilon, delta float64) *CountMinSketch {
+	if epsilon <= 0 {
+		epsilon = 0.001
+	}
+	if delta <= 0 || delta >= 1 {
+		delta = 0.01
+	}
+
+	width := uint64(math.Ceil(math.E / epsilon))
+	depth := int(math.Ceil(math.Log(1 / delta)))
+	counts := make([][]uint64, depth)
+	for i := range counts {
+		counts[i] = make([]uint64, width)
+	}
+	return &CountMinSketch{width: width, counts: counts}
+}
+
+// Add add count to key
+func (c *CountMinSketch) Add(key any, count uint64) { c.AddHash(hashKey(key), count) }
+
+// AddHash add count to 64-bit hash of key
+func (c *CountMinSketch) AddHash(hash uint64, count uint64) {
+	c.total += count
+	for i, h1, h2 := 0, hash, mix64(hash)|1; i < len(c.counts); i++ {
+		c.counts[i][(h1+uint64(i)*h2)%c.width] += count
+	}
+}
+
+// Estimate return estimated frequency of key
+func (c *CountMinSketch) Estimate(key any) uint64 { return c.EstimateHash(hashKey(key)) }
+
+// EstimateHash return estimated frequency of 64-bit hash of key
+func (c *CountMinSketch) EstimateHash(hash uint64) uint64 {
+	estimate := uint64(math.MaxUint64)
+	for i, h1, h2 := 0, hash, mix64(hash)|1; i < len(c.counts); i++ {
+		if count := c.counts[i][(h1+uint64(i)*h2)%c.width]; count < estimate {
+			estimate = count
+		}
+	}
+	return estimate
+}
+
+// Total return total count added
+func (c *CountMinSketch) Total() uint64 { return c.total }
+
+// Merge merge other into c, both must have the same epsilon and delta
+func (c *CountMinSketch) Merge(other *CountMinSketch) error {
+	if c.width != other.width || len(c.counts) != len(other.counts) {
+		return ErrSketchMismatch
+	}
+	for i, row := range other.counts {
+		for j, count := range row {
+			c.counts[i][j] += count
+		}
+	}
+	c.total += other.total
+	return nil
+}
+
+// Frequency estimated frequency of item, true count is between Count-Error and Count
+type Frequency[T any] struct {
+	Item  T
+	Count uint64
+	Error uint64
+}
+
+// SpaceSaving heavy hitters estimator keeping k counters, not safe for concurrent use
+type SpaceSaving[T any, K comparable] struct {
+	k        int
+	counters map[K]*ssCounter[T, K]
+	heap     ssHeap[T, K]
+}
+
+type ssCounter[T any, K comparable] struct {
+	key   K
+	freq  Frequency[T]
+	index int
+}
+
+// NewSpaceSaving return Space-Saving estimator keeping k counters
+func NewSpaceSaving[T any, K comparable](k int) *SpaceSaving[T, K] {
+	if k <= 0 {
+		k = 1
+	}
+	return &SpaceSaving[T, K]{k: k, counters: make(map[K]*ssCounter[T, K], k)}
+}
+
+// Add count one occurrence of key, item is reported as representative of key
+func (s *SpaceSaving[T, K]) Add(key K, item T) { s.add(key, Frequency[T]{Item: item, Count: 1}) }
+
+func (s *SpaceSaving[T, K]) add(key K, freq Frequency[T]) {
+	if c, ok := s.counters[key]; ok {
+		c.freq.Count += freq.Count
+		c.freq.Error += freq.Error
+		heap.Fix(&s.heap, c.index)
+		return
+	}
+	if len(s.counters) < s.k {
+		c := &ssCounter[T, K]{key: key, freq: freq}
+		s.counters[key] = c
+		heap.Push(&s.heap, c)
+		return
+	}
+
+	// replace the least frequent counter
+	c := s.heap[0]
+	delete(s.counters, c.key)
+	c.key, c.freq = key, Frequency[T]{Item: freq.Item, Count: c.freq.Count + freq.Count, Error: c.freq.Count + freq.Error}
+	s.counters[key] = c
+	heap.Fix(&s.heap, 0)
+}
+
+// Merge merge counters of other into s
+func (s *SpaceSaving[T, K]) Merge(other *SpaceSaving[T, K]) {
+	for _, c := range other.heap {
+		s.add(c.key, c.freq)
+	}
+}
+
+// Top return counters in descending count order
+func (s *SpaceSaving[T, K]) Top() []Frequency[T] {
+	results := make([]Frequency[T], 0, len(s.heap))
+	for _, c := range s.heap {
+		results = append(results, c.freq)
+	}
+	sort.SliceStable(results, func(i, j int) bool { return results[i].Count > results[j].Count })
+	return results
+}
+
+// ssHeap min heap of counters by count
+type ssHeap[T any, K comparable] []*ssCounter[T, K]
+
+func (h ssHeap[T, K]) Len() int           { return len(h) }
+func (h ssHeap[T, K]) Less(i, j int) bool { return h[i].freq.Count < h[j].freq.Count }
+func (h ssHeap[T, K]) Swap(i, j int) {
+	h[i], h[j] = h[j], h[i]
+	h[i].index, h[j].index = i, j
+}
+func (h *ssHeap[T, K]) Push(x any) {
+	c := x.(*ssCounter[T, K])
+	c.index = len(*h)
+	*h = append(*h, c)
+}
+func (h *ssHeap[T, K]) Pop() any {
+	old := *h
+	c := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return c
+}
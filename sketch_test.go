@@ -0,0 +1,49 @@
+package stream_test
+
+import (
+	"testing"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestSketches(t *testing.T) {
+	data := make([]int, 0, 20000)
+	for i := 0; i < 10000; i++ {
+		data = append(data, i, i%10)
+	}
+
+	if count := stream.CountDistinctApprox(stream.SliceOf(data...).Parallel(4), 12, func(i int) int { return i }); count < 9500 || count > 10500 {
+		t.Errorf("CountDistinctApprox: expect about 10000, got %d", count)
+	}
+
+	type point struct{ X, Y int }
+	p1, p2 := &point{1, 2}, &point{1, 2}
+	self := func(p *point) *point { return p }
+	if count := stream.CountDistinctApprox(stream.SliceOf(p1, p2), 12, self); count != 2 {
+		t.Errorf("CountDistinctApprox: expect 2 distinct pointers, got %d", count)
+	}
+	if count := stream.FrequencyEstimator(stream.SliceOf(p1, p2, p2), 0.001, 0.01, self).Estimate(p1); count != 1 {
+		t.Errorf("FrequencyEstimator: expect 1 for first pointer, got %d", count)
+	}
+	if hitters := stream.HeavyHitters(stream.SliceOf(p1, p2, p2), 2, self); len(hitters) != 2 || hitters[0].Item != p2 {
+		t.Errorf("HeavyHitters: expect second pointer first in 2 counters, got %v", hitters)
+	}
+
+	estimator := stream.FrequencyEstimator(stream.SliceOf(data...).Parallel(4), 0.001, 0.01, func(i int) int { return i })
+	if count := estimator.Estimate(3); count < 1001 || count > 1001+20 {
+		t.Errorf("FrequencyEstimator: expect about 1001 for 3, got %d", count)
+	}
+	if total := estimator.Total(); total != 20000 {
+		t.Errorf("FrequencyEstimator: expect total 20000, got %d", total)
+	}
+
+	hitters := stream.HeavyHitters(stream.SliceOf(data...), 20, func(i int) int { return i % 100 })
+	if len(hitters) != 20 {
+		t.Fatalf("HeavyHitters: expect 20 counters, got %d", len(hitters))
+	}
+	for _, h := range hitters[:10] {
+		if h.Item%100 >= 10 {
+			t.Errorf("HeavyHitters: expect key under 10 in top 10, got %d with count %d", h.Item%100, h.Count)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package stream_test
+
+import (
+	"context"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/tr1v3r/stream"
+	"github.com/tr1v3r/stream/types"
+)
+
+func TestExternalSort(t *testing.T) {
+	array := []int{9, 4, 7, 1, 8, 2, 6, 3, 5, 0}
+	expect := []int{0, 1, 2, 3, 4, 5, 6, 7, 8, 9}
+
+	for _, codec := range []stream.Codec{stream.GobCodec{}, stream.JSONCodec{}} {
+		dir := t.TempDir()
+		s := stream.SliceOf(array...).ExternalSort(func(l, r int) int { return l - r },
+			stream.ExternalSortOptions{RunSize: 3, TempDir: dir, Codec: codec})
+		if result := s.ToSlice(); !reflect.DeepEqual(result, expect) {
+			t.Errorf("%T: expect %v, got %v", codec, expect, result)
+		}
+		if err := s.Err(); err != nil {
+			t.Errorf("%T: unexpected error: %v", codec, err)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("%T: expect temp files removed, got %d entries", codec, len(entries))
+		}
+	}
+
+	result := stream.SliceOf(array...).Parallel(4).
+		ExternalSort(func(l, r int) int { return r - l }, stream.ExternalSortOptions{RunSize: 4, TempDir: t.TempDir()}).
+		ToSlice()
+	if !reflect.DeepEqual(result, []int{9, 8, 7, 6, 5, 4, 3, 2, 1, 0}) {
+		t.Errorf("expect reverse order, got %v", result)
+	}
+}
+
+func TestExternalSortStable(t *testing.T) {
+	type item struct{ Key, Seq int }
+	items := make([]item, 200)
+	for i := range items {
+		items[i] = item{Key: (i * 7) % 5, Seq: i}
+	}
+
+	result := stream.SliceOf(items...).
+		ExternalSort(func(l, r item) int { return l.Key - r.Key }, stream.ExternalSortOptions{RunSize: 64, TempDir: t.TempDir()}).
+		ToSlice()
+	if len(result) != len(items) {
+		t.Fatalf("expect %d elements, got %d", len(items), len(result))
+	}
+	for i := 1; i < len(result); i++ {
+		if prev, cur := result[i-1], result[i]; prev.Key > cur.Key || (prev.Key == cur.Key && prev.Seq > cur.Seq) {
+			t.Fatalf("expect equal keys in stream order, got %v before %v", prev, cur)
+		}
+	}
+}
+
+func TestExternalSortCancel(t *testing.T) {
+	dir := t.TempDir()
+	ctx, cancel := context.WithCancel(context.Background())
+
+	first := stream.SliceOf(5, 3, 1, 4, 2).WithContext(ctx).
+		ExternalSort(func(l, r int) int { return l - r }, stream.ExternalSortOptions{RunSize: 2, TempDir: dir}).
+		First()
+	if first != 1 {
+		t.Errorf("expect 1, got %d", first)
+	}
+
+	cancel()
+	for i := 0; i < 100; i++ {
+		if entries, _ := os.ReadDir(dir); len(entries) == 0 {
+			return
+		}
+		time.Sleep(time.Millisecond)
+	}
+	t.Errorf("expect temp files removed after cancel")
+}
+
+func TestExternalSortEarlyStop(t *testing.T) {
+	byValue := func(l, r int) int { return l - r }
+	for name, consume := range map[string]func(stream.Streamer[int]) []int{
+		"First":    func(s stream.Streamer[int]) []int { return []int{s.First()} },
+		"Limit":    func(s stream.Streamer[int]) []int { return s.Limit(1).ToSlice() },
+		"AnyMatch": func(s stream.Streamer[int]) []int { s.AnyMatch(func(int) bool { return true }); return []int{1} },
+		"async":    func(s stream.Streamer[int]) []int { return s.Parallel(2).Limit(1).ToSlice() },
+	} {
+		dir := t.TempDir()
+		s := stream.SliceOf(5, 3, 1, 4, 2).ExternalSort(byValue, stream.ExternalSortOptions{RunSize: 2, TempDir: dir})
+		if result := consume(s); !reflect.DeepEqual(result, []int{1}) {
+			t.Errorf("%s: expect [1], got %v", name, result)
+		}
+		if entries, _ := os.ReadDir(dir); len(entries) != 0 {
+			t.Errorf("%s: expect temp files removed once consumer stops, got %d entries", name, len(entries))
+		}
+	}
+}
+
+func TestSortStable(t *testing.T) {
+	type record struct {
+		Name  *string
+		Score int
+		Seq   int
+	}
+	name := func(s string) *string { return &s }
+
+	records := []record{
+		{name("b"), 1, 0}, {nil, 2, 1}, {name("a"), 2, 2}, {name("b"), 2, 3},
+		{name("a"), 1, 4}, {nil, 1, 5}, {name("a"), 2, 6},
+	}
+	byScoreDesc := types.Comparing(func(r record) int { return r.Score }).Reversed()
+	byName := types.ComparingWith(func(r record) *string { return r.Name }, types.NullsLast(types.Natural[string]()))
+
+	var seqs []int
+	for _, r := range stream.SliceOf(records...).SortStable(byScoreDesc.ThenComparing(byName)).ToSlice() {
+		seqs = append(seqs, r.Seq)
+	}
+	if expect := []int{2, 6, 3, 1, 4, 0, 5}; !reflect.DeepEqual(seqs, expect) {
+		t.Errorf("expect %v, got %v", expect, seqs)
+	}
+}
@@ -2,6 +2,7 @@ package stream
 
 import (
 	"context"
+	"io"
 	"math/rand"
 	"sort"
 	"time"
@@ -13,21 +14,22 @@ var (
 	_ Streamer[any]     = newStreamer[any](nil)
 	_ Streamer[float64] = newStreamer[float64](nil)
 	// seededRand provides a seeded random source for non-cryptographic use
-	seededRand = rand.New(rand.NewSource(time.Now().UnixNano()))
+	seededRand = rand.New(&lockedSource{src: rand.NewSource(time.Now().UnixNano())})
 )
 
 var ctx = context.Background()
 
-type stage[T any] func(iterator[T]) iterator[T]
+// stage build iterator of stage results on source, errors of the run are recorded to rec
+type stage[T any] func(rec *errRecorder, source iterator[T]) iterator[T]
 
 // newStreamer return streamer
 func newStreamer[T any](iter iterator[T]) *streamer[T] {
-	return &streamer[T]{ctx: ctx, source: iter, stage: func(iter iterator[T]) iterator[T] { return iter }}
+	return &streamer[T]{ctx: withRecorder(ctx), source: iter, stage: func(_ *errRecorder, iter iterator[T]) iterator[T] { return iter }, sized: true, last: new(lastRun)}
 }
 
 // wrapStreamer wrap stage to new streamer
 func wrapStreamer[T any](source iterator[T], stage stage[T]) *streamer[T] {
-	return &streamer[T]{ctx: ctx, source: source, stage: stage}
+	return &streamer[T]{ctx: ctx, source: source, stage: stage, last: new(lastRun)}
 }
 
 // streamer underlying streamer implement for Streamer
@@ -36,16 +38,61 @@ type streamer[T any] struct {
 
 	source iterator[T]
 	stage  stage[T]
+	sized  bool     // stage keeps size of source, so infinite source stays infinite
+	last   *lastRun // recorder of the latest run, reported by Err
 }
 
 // WithContext set stream context
 func (s streamer[T]) WithContext(ctx context.Context) Streamer[T] {
-	s.ctx = ctx
+	s.ctx, s.last = inheritValues(ctx, s.ctx), new(lastRun)
+	return &s
+}
+
+// WithRand set stream random source
+func (s streamer[T]) WithRand(src rand.Source) Streamer[T] {
+	s.ctx, s.last = withRand(s.ctx, src), new(lastRun)
 	return &s
 }
 
 func (s *streamer[T]) cancelled() bool { return s.ctx.Err() != nil }
 
+// run run stages on a clone of source with a new run recorder, so that the streamer can be consumed many times
+// and errors of one run are not reported by another
+func (s *streamer[T]) run() (iterator[T], *errRecorder) {
+	rec := newRunRecorder(s.ctx)
+	s.last.Store(rec)
+	return s.runWith(rec), rec
+}
+
+// runWith run stages on a clone of source, errors of the run are recorded to rec
+func (s *streamer[T]) runWith(rec *errRecorder) iterator[T] {
+	source := s.source.Clone()
+	if bound, ok := source.(interface{ bindContext(context.Context) }); ok {
+		bound.bindContext(withRunRecorder(s.ctx, rec))
+	}
+	return s.stage(rec, source)
+}
+
+// finite report whether consuming all elements of source ends,
+// ErrInfiniteStream is recorded to rec for known infinite source without a cancellable context
+func (s *streamer[T]) finite(rec *errRecorder, source iterator[T]) bool {
+	if source.Size() == sizeInfinite && s.ctx.Done() == nil {
+		rec.record(ErrInfiniteStream)
+		return false
+	}
+	return true
+}
+
+// pull return function pulling elements from source one by one until source exhausted or stream cancelled
+func (s *streamer[T]) pull(source iterator[T]) func() (T, bool) {
+	return func() (t T, ok bool) {
+		if s.cancelled() || !source.HasNext() {
+			return t, false
+		}
+		return source.Next(), true
+	}
+}
+
 // Append append data to streamer source
 func (s *streamer[T]) Append(data ...T) Streamer[T] {
 	return newStreamer(s.source.Concat(newIterator(data))).WithContext(s.ctx)
@@ -53,90 +100,200 @@ func (s *streamer[T]) Append(data ...T) Streamer[T] {
 
 // Execute eager execute on source
 func (s *streamer[T]) Execute() Streamer[T] {
-	return newStreamer(s.stage(s.source)).WithContext(s.ctx)
+	source, _ := s.run()
+	return newStreamer(source).WithContext(s.ctx)
 }
 
 func (s streamer[T]) Parallel(n int) Streamer[T] {
 	if n <= 0 {
 		return &s
 	}
-	return wrapAsyncStreamer(n, func() <-chan T {
-		ch := make(chan T, 1024)
+	infinite := s.sized && s.source.Size() == sizeInfinite
+	return wrapAsyncStreamer(asyncOptions{parallelSize: n, infinite: infinite}, func(ctx context.Context, batchSize int) <-chan []T {
+		ch := make(chan []T, bufferOf(batchSize))
 		go func() {
 			defer close(ch)
-			for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
-				ch <- source.Next()
-			}
+
+			bound := s // sources blocking for more elements stop with stage
+			bound.ctx = inheritValues(ctx, s.ctx)
+			source := bound.runWith(runRecorderOf(ctx))
+			defer closeIter(source)
+			sendBatches(ctx, ch, s.pull(source), batchSize)
 		}()
 		return ch
 	}).WithContext(s.ctx)
 }
 
+// WithExecutor run stream asynchronously on executor
+func (s *streamer[T]) WithExecutor(executor Executor) Streamer[T] {
+	return s.Parallel(1).WithExecutor(executor)
+}
+
+// WithBatchSize run stream asynchronously, elements are handed over to workers in batches of size
+func (s *streamer[T]) WithBatchSize(size int) Streamer[T] {
+	return s.Parallel(1).WithBatchSize(size)
+}
+
 func (s *streamer[T]) Filter(judge types.Judge[T]) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
+	return s.filterWith(func() types.Judge[T] { return judge })
+}
+
+// filterWith filter data by Judge built for each run, for judges holding state
+func (s *streamer[T]) filterWith(newJudge func() types.Judge[T]) Streamer[T] {
+	filtered := wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		judge, source := newJudge(), s.stage(rec, source)
+		if source.Size() < 0 { // pull lazily, source may never end
+			next := s.pull(source)
+			return lazyOf(source, func() (T, bool) {
+				for {
+					if item, ok := next(); !ok || judge(item) {
+						return item, ok
+					}
+				}
+			})
+		}
+
+		results := []T{}
 		for !s.cancelled() && source.HasNext() {
 			if item := source.Next(); judge(item) {
 				results = append(results, item)
 			}
 		}
 		return newIterator(results)
-	}).WithContext(s.ctx)
+	})
+	filtered.sized = s.sized
+	return filtered.WithContext(s.ctx)
 }
 func (s *streamer[T]) Map(m types.Mapper[T]) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
+	mapped := wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source = s.stage(rec, source)
+		if source.Size() < 0 {
+			next := s.pull(source)
+			return lazyOf(source, func() (t T, ok bool) {
+				if t, ok = next(); ok {
+					t = m(t)
+				}
+				return t, ok
+			})
+		}
+
+		results := []T{}
 		for !s.cancelled() && source.HasNext() {
 			results = append(results, m(source.Next()))
 		}
 		return newIterator(results)
-	}).WithContext(s.ctx)
+	})
+	mapped.sized = s.sized
+	return mapped.WithContext(s.ctx)
 }
 func (s *streamer[T]) Convert(convert types.Converter[T, any]) Streamer[any] {
-	return wrapStreamer(wrapAny(s.source), func(source iterator[any]) iterator[any] {
-		source, results := wrapAny(s.stage(deWrapAny[T](source))), []any{}
-		for !s.cancelled() && source.HasNext() {
-			results = append(results, convert(source.Next().(T)))
+	converted := wrapStreamer(wrapAny(s.source), func(rec *errRecorder, source iterator[any]) iterator[any] {
+		stage := s.stage(rec, deWrapAny[T](source))
+		if stage.Size() < 0 {
+			next := s.pull(stage)
+			return lazyOf(stage, func() (any, bool) {
+				if t, ok := next(); ok {
+					return convert(t), true
+				}
+				return nil, false
+			})
+		}
+
+		results := []any{}
+		for !s.cancelled() && stage.HasNext() {
+			results = append(results, convert(stage.Next()))
 		}
 		return newIterator(results)
-	}).WithContext(s.ctx)
+	})
+	converted.sized = s.sized
+	return converted.WithContext(s.ctx)
 }
 func (s *streamer[T]) Peek(consumer types.Consumer[T]) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
+	peeked := wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source = s.stage(rec, source)
+		if source.Size() < 0 {
+			next := s.pull(source)
+			return lazyOf(source, func() (t T, ok bool) {
+				if t, ok = next(); ok {
+					consumer(t)
+				}
+				return t, ok
+			})
+		}
+
+		results := []T{}
 		for !s.cancelled() && source.HasNext() {
 			item := source.Next()
 			consumer(item)
 			results = append(results, item)
 		}
 		return newIterator(results)
-	}).WithContext(s.ctx)
+	})
+	peeked.sized = s.sized
+	return peeked.WithContext(s.ctx)
+}
+
+func (s *streamer[T]) SampleRate(p float64) Streamer[T] {
+	r := randOf(s.ctx)
+	return s.Filter(func(T) bool { return r.Float64() < p })
 }
 
-func (s *streamer[T]) Distinct() Streamer[T] { return s.Filter(distinctJudge[T]()) }
+func (s *streamer[T]) Distinct() Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctJudge[T](1) })
+}
+func (s *streamer[T]) DistinctWithin(n int) Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctWithinJudge[T](n) })
+}
+func (s *streamer[T]) DistinctTTL(ttl time.Duration, opts ...DistinctTTLOptions) Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctTTLJudge[T](ttl, opts...) })
+}
+func (s *streamer[T]) DistinctApprox(expectedItems int, fpRate float64) Streamer[T] {
+	return s.filterWith(func() types.Judge[T] { return distinctApproxJudge[T](expectedItems, fpRate) })
+}
 func (s *streamer[T]) Sort(comparator types.Comparator[T]) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
-		for !s.cancelled() && source.HasNext() {
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source, results := s.stage(rec, source), []T{}
+		for s.finite(rec, source) && !s.cancelled() && source.HasNext() {
 			results = append(results, source.Next())
 		}
 		sort.Sort(&Sortable[T]{List: results, Cmp: comparator})
 		return newIterator(results)
 	}).WithContext(s.ctx)
 }
+func (s *streamer[T]) SortStable(comparator types.Comparator[T]) Streamer[T] {
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source, results := s.stage(rec, source), []T{}
+		for s.finite(rec, source) && !s.cancelled() && source.HasNext() {
+			results = append(results, source.Next())
+		}
+		sort.Stable(&Sortable[T]{List: results, Cmp: comparator})
+		return newIterator(results)
+	}).WithContext(s.ctx)
+}
 func (s *streamer[T]) ReverseSort(comparator types.Comparator[T]) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
-		for !s.cancelled() && source.HasNext() {
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source, results := s.stage(rec, source), []T{}
+		for s.finite(rec, source) && !s.cancelled() && source.HasNext() {
 			results = append(results, source.Next())
 		}
 		sort.Sort(sort.Reverse(&Sortable[T]{List: results, Cmp: comparator}))
 		return newIterator(results)
 	}).WithContext(s.ctx)
 }
+func (s *streamer[T]) ExternalSort(comparator types.Comparator[T], opts ExternalSortOptions) Streamer[T] {
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		if source = s.stage(rec, source); !s.finite(rec, source) {
+			return newIterator[T](nil)
+		}
+		return externalSort(s.ctx, rec, s.pull(source), comparator, opts)
+	}).WithContext(s.ctx)
+}
 func (s *streamer[T]) Reverse() Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		results := s.stage(source).Left()
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		if source = s.stage(rec, source); !s.finite(rec, source) {
+			return newIterator[T](nil)
+		}
+		results := source.Left()
 		for i, length := 0, len(results)-1; i <= length/2; i++ {
 			results[i], results[length-i] = results[length-i], results[i]
 		}
@@ -144,8 +301,9 @@ func (s *streamer[T]) Reverse() Streamer[T] {
 	}).WithContext(s.ctx)
 }
 func (s *streamer[T]) Limit(l int64) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source, results := s.stage(rec, source), []T{}
+		defer closeIter(source)
 		for i := 0; i < int(l) && !s.cancelled() && source.HasNext(); i++ {
 			results = append(results, source.Next())
 		}
@@ -153,30 +311,38 @@ func (s *streamer[T]) Limit(l int64) Streamer[T] {
 	}).WithContext(s.ctx)
 }
 func (s *streamer[T]) Skip(n int64) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source = s.stage(source)
+	skipped := wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source = s.stage(rec, source)
 		source.NextN(n) // skip n
 		return source
-		// return newIterator[T](source.Left()) Left cannot be called on supply iterator
-	}).WithContext(s.ctx)
+	})
+	skipped.sized = s.sized
+	return skipped.WithContext(s.ctx)
 }
 func (s *streamer[T]) Pick(start, end, interval int) Streamer[T] {
-	return wrapStreamer(s.source, func(source iterator[T]) iterator[T] {
-		source, results := s.stage(source), []T{}
+	return wrapStreamer(s.source, func(rec *errRecorder, source iterator[T]) iterator[T] {
+		source, results := s.stage(rec, source), []T{}
+		defer closeIter(source)
 
-		// if end is negative, set end to source size
-		if end < 0 {
-			end = int(source.Size())
+		// invalid range, return empty
+		if start < 0 || (end >= 0 && start > end) || interval <= 0 {
+			return newIterator(results)
 		}
-
-		// start out of range or start > end or interval == 0, return empty
-		if start < 0 || int64(start) >= source.Size() || start > end || interval < 0 {
+		// negative end means picking to the end, which needs a finite source
+		if end < 0 && !s.finite(rec, source) {
 			return newIterator(results)
 		}
 
-		results = append(results, source.NextN(int64(start+1))) // pick first
-		for !s.cancelled() && source.CurIndex()+int64(interval)-1 <= int64(end) && source.HasNextN(int64(interval)) {
-			results = append(results, source.NextN(int64(interval)))
+		// count index as it goes, so that source size is not needed
+		next, index := s.pull(source), int64(0)
+		for target := int64(start); end < 0 || target <= int64(end); target += int64(interval) {
+			var item T
+			for ok := false; index <= target; index++ {
+				if item, ok = next(); !ok {
+					return newIterator(results)
+				}
+			}
+			results = append(results, item)
 		}
 		return newIterator(results)
 	}).WithContext(s.ctx)
@@ -188,15 +354,20 @@ func (s *streamer[T]) Collect(to types.Collector[T]) any {
 	return to(s.ToSlice()...)
 }
 func (s *streamer[T]) ForEach(consumer types.Consumer[T]) {
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	for source, _ := s.run(); !s.cancelled() && source.HasNext(); {
 		consumer(source.Next())
 	}
 }
 func (s *streamer[T]) ToSlice() []T {
-	return s.stage(s.source).Left()
+	if source, rec := s.run(); s.finite(rec, source) {
+		return source.Left()
+	}
+	return nil
 }
 func (s *streamer[T]) AllMatch(judge types.Judge[T]) bool {
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	source, _ := s.run()
+	defer closeIter(source)
+	for !s.cancelled() && source.HasNext() {
 		if item := source.Next(); !judge(item) {
 			return false
 		}
@@ -204,7 +375,9 @@ func (s *streamer[T]) AllMatch(judge types.Judge[T]) bool {
 	return true
 }
 func (s *streamer[T]) NonMatch(judge types.Judge[T]) bool {
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	source, _ := s.run()
+	defer closeIter(source)
+	for !s.cancelled() && source.HasNext() {
 		if item := source.Next(); judge(item) {
 			return false
 		}
@@ -212,7 +385,9 @@ func (s *streamer[T]) NonMatch(judge types.Judge[T]) bool {
 	return true
 }
 func (s *streamer[T]) AnyMatch(judge types.Judge[T]) bool {
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	source, _ := s.run()
+	defer closeIter(source)
+	for !s.cancelled() && source.HasNext() {
 		if item := source.Next(); judge(item) {
 			return true
 		}
@@ -221,41 +396,128 @@ func (s *streamer[T]) AnyMatch(judge types.Judge[T]) bool {
 }
 func (s *streamer[T]) Reduce(accumulator types.BinaryOperator[T]) T {
 	var result T
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	for source, rec := s.run(); s.finite(rec, source) && !s.cancelled() && source.HasNext(); {
 		result = accumulator(result, source.Next())
 	}
 	return result
 }
 func (s *streamer[T]) ReduceFrom(initValue T, accumulator types.BinaryOperator[T]) T {
 	result := initValue
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	for source, rec := s.run(); s.finite(rec, source) && !s.cancelled() && source.HasNext(); {
 		result = accumulator(result, source.Next())
 	}
 	return result
 }
 func (s *streamer[T]) ReduceWith(initValue any, accumulator types.Accumulator[T, any]) any {
 	result := initValue
-	for source := s.stage(s.source); !s.cancelled() && source.HasNext(); {
+	for source, rec := s.run(); s.finite(rec, source) && !s.cancelled() && source.HasNext(); {
 		result = accumulator(result, source.Next())
 	}
 	return result
 }
 func (s *streamer[T]) ReduceBy(initValueBulider func(sizeMayNegative int) any, accumulator types.Accumulator[T, any]) any {
-	source := s.stage(s.source)
-	result := initValueBulider(int(source.Size()))
-	for !s.cancelled() && source.HasNext() {
+	source, rec := s.run()
+	size := leftSize(source)
+	if size < 0 {
+		size = sizeUnknown
+	}
+	result := initValueBulider(int(size))
+	for s.finite(rec, source) && !s.cancelled() && source.HasNext() {
 		result = accumulator(result, source.Next())
 	}
 	return result
 }
-func (s *streamer[T]) First() T { return s.stage(s.source).Next() }
-func (s *streamer[T]) Take() T {
-	source := s.stage(s.source)
-	return source.NextN(seededRand.Int63n(source.Size()) + 1)
+func (s *streamer[T]) First() T { t, _ := s.FirstOK(); return t }
+func (s *streamer[T]) Take() T  { t, _ := s.TakeOK(); return t }
+func (s *streamer[T]) Any() T   { return s.Take() }
+func (s *streamer[T]) Last() T  { t, _ := s.LastOK(); return t }
+func (s *streamer[T]) FirstOK() (t T, ok bool) {
+	source, _ := s.run()
+	defer closeIter(source)
+	if !s.cancelled() && source.HasNext() {
+		return source.Next(), true
+	}
+	return t, false
 }
-func (s *streamer[T]) Any() T { return s.Take() }
-func (s *streamer[T]) Last() T {
-	source := s.stage(s.source)
-	return source.NextN(source.Size())
+func (s *streamer[T]) TakeOK() (t T, ok bool) {
+	source, rec := s.run()
+	if !s.finite(rec, source) {
+		return t, false
+	}
+	if source.Size() < 0 { // unknown size, sample in one pass
+		if sample := reservoirSample(s.pull(source), 1, randOf(s.ctx)); len(sample) == 1 {
+			return sample[0], true
+		}
+		return t, false
+	}
+	if left := source.Size() - source.CurIndex(); left > 0 && !s.cancelled() {
+		return source.NextN(randOf(s.ctx).Int63n(left) + 1), true
+	}
+	return t, false
+}
+func (s *streamer[T]) AnyOK() (T, bool) { return s.TakeOK() }
+func (s *streamer[T]) LastOK() (t T, ok bool) {
+	source, rec := s.run()
+	if !s.finite(rec, source) {
+		return t, false
+	}
+	if source.Size() < 0 { // unknown size, keep the last one
+		next := s.pull(source)
+		for item, more := next(); more; item, more = next() {
+			t, ok = item, true
+		}
+		return t, ok
+	}
+	if left := source.Size() - source.CurIndex(); left > 0 && !s.cancelled() {
+		return source.NextN(left), true
+	}
+	return t, false
+}
+func (s *streamer[T]) TopK(k int, comparator types.Comparator[T]) []T {
+	return topK[T](s, k, comparator)
+}
+func (s *streamer[T]) BottomK(k int, comparator types.Comparator[T]) []T {
+	return topK[T](s, k, comparator.Reversed())
+}
+func (s *streamer[T]) Sample(k int) []T {
+	if source, rec := s.run(); s.finite(rec, source) {
+		return reservoirSample(s.pull(source), k, randOf(s.ctx))
+	}
+	return nil
+}
+func (s *streamer[T]) SampleWeighted(k int, weight func(T) float64) []T {
+	if source, rec := s.run(); s.finite(rec, source) {
+		return weightedSample(s.pull(source), k, weight, randOf(s.ctx))
+	}
+	return nil
+}
+func (s *streamer[T]) Count() (count int64) {
+	source, rec := s.run()
+	if size := leftSize(source); size >= 0 {
+		return size
+	}
+	if !s.finite(rec, source) {
+		return 0
+	}
+	for next := s.pull(source); ; count++ {
+		if _, ok := next(); !ok {
+			return count
+		}
+	}
+}
+func (s *streamer[T]) EncodeJSONLines(w io.Writer) error {
+	source, rec := s.run()
+	if !s.finite(rec, source) {
+		return ErrInfiniteStream
+	}
+	if err := encodeJSONLines(s.pull(source), w); err != nil {
+		return err
+	}
+	return rec.Err()
+}
+func (s *streamer[T]) Err() error {
+	if rec := s.last.Load(); rec != nil {
+		return rec.Err()
+	}
+	return recorderOf(s.ctx).Err()
 }
-func (s *streamer[T]) Count() int64 { return s.stage(s.source).Size() }
@@ -0,0 +1,174 @@
+package stream
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"sort"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// TableOptions options for PrintTable
+type TableOptions struct {
+	// Columns field names of struct or keys of map to print in order, default all fields,
+	// or sorted keys of the first map. Fields of untagged nested structs are flattened like WriteCSV.
+	Columns []string
+	// MaxWidth truncate values longer than MaxWidth runes, 0 means no truncation
+	MaxWidth int
+	// Nil text of nil pointers, default "<nil>"
+	Nil string
+}
+
+// PrintTable print elements of s to w as aligned columns with a header row, for inspection in tests and CLIs.
+// Structs, pointers to structs and maps are split into columns, pointer fields are followed,
+// other elements are printed in one column named "value". Elements are consumed serially in stream order.
+func PrintTable[T any](s Streamer[T], w io.Writer, opts ...TableOptions) error {
+	var opt TableOptions
+	if len(opts) > 0 {
+		opt = opts[0]
+	}
+	if opt.Nil == "" {
+		opt.Nil = "<nil>"
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+	p := &tablePrinter{opts: opt, w: tw}
+	next, stop := pullAll(s)
+	defer stop()
+	for t, ok := next(); ok; t, ok = next() {
+		if err := p.print(reflect.ValueOf(&t).Elem()); err != nil {
+			return err
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	return s.Err()
+}
+
+// tablePrinter print rows, columns are decided by the first row
+type tablePrinter struct {
+	opts TableOptions
+	w    io.Writer
+
+	columns []string
+	cells   func(reflect.Value) []string
+}
+
+func (p *tablePrinter) print(value reflect.Value) error {
+	if p.cells == nil {
+		if err := p.init(value); err != nil {
+			return err
+		}
+		if err := p.row(p.columns); err != nil {
+			return err
+		}
+	}
+	return p.row(p.cells(value))
+}
+
+func (p *tablePrinter) init(value reflect.Value) error {
+	typ := indirectType(value.Type())
+	switch {
+	case typ.Kind() == reflect.Struct && typ != timeType:
+		fields := textFields(typ, "table", nil)
+		if len(p.opts.Columns) > 0 {
+			byName := make(map[string]*textField, len(fields))
+			for _, f := range fields {
+				byName[f.name] = f
+			}
+			fields = fields[:0]
+			for _, name := range p.opts.Columns {
+				f, ok := byName[name]
+				if !ok {
+					return fmt.Errorf("print table: no column %q in %s", name, typ)
+				}
+				fields = append(fields, f)
+			}
+		}
+		for _, f := range fields {
+			p.columns = append(p.columns, f.name)
+		}
+		p.cells = func(value reflect.Value) []string {
+			cells, value := make([]string, len(fields)), indirect(value)
+			for i, f := range fields {
+				if !value.IsValid() {
+					cells[i] = p.opts.Nil
+				} else if v, ok := f.valueOf(value); ok {
+					cells[i] = p.text(v)
+				} else {
+					cells[i] = p.opts.Nil
+				}
+			}
+			return cells
+		}
+	case typ.Kind() == reflect.Map:
+		if p.columns = p.opts.Columns; len(p.columns) == 0 {
+			for _, key := range indirect(value).MapKeys() {
+				p.columns = append(p.columns, fmt.Sprint(key.Interface()))
+			}
+			sort.Strings(p.columns)
+		}
+		p.cells = func(value reflect.Value) []string {
+			values := make(map[string]reflect.Value)
+			if m := indirect(value); m.IsValid() {
+				for iter := m.MapRange(); iter.Next(); {
+					values[fmt.Sprint(iter.Key().Interface())] = iter.Value()
+				}
+			}
+			cells := make([]string, len(p.columns))
+			for i, column := range p.columns {
+				if v, ok := values[column]; ok {
+					cells[i] = p.text(v)
+				}
+			}
+			return cells
+		}
+	default:
+		p.columns = []string{"value"}
+		p.cells = func(value reflect.Value) []string { return []string{p.text(value)} }
+	}
+	return nil
+}
+
+func (p *tablePrinter) row(cells []string) error {
+	_, err := io.WriteString(p.w, strings.Join(cells, "\t")+"\n")
+	return err
+}
+
+// text return display text of value following pointers and interfaces
+func (p *tablePrinter) text(value reflect.Value) string {
+	if value = indirect(value); !value.IsValid() {
+		return p.opts.Nil
+	}
+
+	var text string
+	if value.Type() == timeType {
+		text = value.Interface().(time.Time).Format(time.RFC3339)
+	} else {
+		text = fmt.Sprint(value.Interface())
+	}
+	text = strings.Map(func(r rune) rune {
+		if r == '\t' || r == '\n' || r == '\r' {
+			return ' '
+		}
+		return r
+	}, text)
+	if runes := []rune(text); p.opts.MaxWidth > 0 && len(runes) > p.opts.MaxWidth {
+		text = string(runes[:max(p.opts.MaxWidth-1, 0)]) + "…"
+	}
+	return text
+}
+
+// indirect follow pointers and interfaces, return invalid value if any of them is nil
+func indirect(value reflect.Value) reflect.Value {
+	for value.Kind() == reflect.Pointer || value.Kind() == reflect.Interface {
+		if value.IsNil() {
+			return reflect.Value{}
+		}
+		value = value.Elem()
+	}
+	return value
+}
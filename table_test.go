@@ -0,0 +1,46 @@
+package stream_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+	"github.com/tr1v3r/stream/tests"
+)
+
+func TestPrintTable(t *testing.T) {
+	name, city := "alice", "a very long city name"
+	employees := stream.SliceOf(
+		&tests.Employee{ID: 1, Name: &name, Age: 30, Position: &tests.PositionInfo{City: &city}},
+		&tests.Employee{ID: 22, Age: 4},
+		nil,
+	)
+
+	var buf bytes.Buffer
+	if err := stream.PrintTable(employees, &buf, stream.TableOptions{Columns: []string{"ID", "Name", "City"}, MaxWidth: 10}); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	expect := "ID     Name   City\n" +
+		"1      alice  a very lo…\n" +
+		"22     <nil>  <nil>\n" +
+		"<nil>  <nil>  <nil>\n"
+	if buf.String() != expect {
+		t.Errorf("expect\n%s\ngot\n%s", expect, buf.String())
+	}
+
+	if err := stream.PrintTable(employees, &buf, stream.TableOptions{Columns: []string{"Salary"}}); err == nil {
+		t.Errorf("expect unknown column error")
+	}
+
+	buf.Reset()
+	rows := stream.SliceOf(map[string]any{"b": 2, "a": "x\ty"}, map[string]any{"a": 1})
+	if err := stream.PrintTable(rows, &buf); err != nil || buf.String() != "a    b\nx y  2\n1    \n" {
+		t.Errorf("map: unexpected table %q %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	if err := stream.PrintTable(stream.Range(8, 11, 1), &buf); err != nil || strings.Join(strings.Fields(buf.String()), " ") != "value 8 9 10" {
+		t.Errorf("value: unexpected table %q %v", buf.String(), err)
+	}
+}
@@ -0,0 +1,64 @@
+package stream
+
+import (
+	"bufio"
+	"io"
+	"text/template"
+)
+
+// RenderTemplate render elements of s to w with tmpl, consuming s serially in stream order.
+//
+// If tmpl defines a template named "rows", it is executed once with a lazy channel of elements to range over,
+// after template "header" executed with nil and before template "footer" executed with number of rows sent,
+// both are optional. Otherwise tmpl is executed for each element.
+func RenderTemplate[T any](s Streamer[T], w io.Writer, tmpl *template.Template) error {
+	bw := bufio.NewWriter(w)
+	next, stop := pullAll(s)
+	defer stop()
+
+	if rows := tmpl.Lookup("rows"); rows == nil {
+		for t, ok := next(); ok; t, ok = next() {
+			if err := tmpl.Execute(bw, t); err != nil {
+				return err
+			}
+		}
+	} else {
+		if header := tmpl.Lookup("header"); header != nil {
+			if err := header.Execute(bw, nil); err != nil {
+				return err
+			}
+		}
+
+		ch, quit, count := make(chan T), make(chan struct{}), 0
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			defer close(ch)
+			for t, ok := next(); ok; t, ok = next() {
+				select {
+				case ch <- t:
+					count++
+				case <-quit:
+					return
+				}
+			}
+		}()
+		err := rows.Execute(bw, (<-chan T)(ch))
+		close(quit)
+		<-done
+		if err != nil {
+			return err
+		}
+
+		if footer := tmpl.Lookup("footer"); footer != nil {
+			if err := footer.Execute(bw, count); err != nil {
+				return err
+			}
+		}
+	}
+
+	if err := bw.Flush(); err != nil {
+		return err
+	}
+	return s.Err()
+}
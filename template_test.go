@@ -0,0 +1,38 @@
+package stream_test
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"text/template"
+
+	"github.com/tr1v3r/stream"
+)
+
+func TestRenderTemplate(t *testing.T) {
+	events := stream.SliceOf(event{1, "a"}, event{2, "b"}, event{3, "c"})
+
+	var buf bytes.Buffer
+	tmpl := template.Must(template.New("line").Parse("{{.ID}}={{.Kind}};"))
+	if err := stream.RenderTemplate(events, &buf, tmpl); err != nil || buf.String() != "1=a;2=b;3=c;" {
+		t.Errorf("per element: unexpected %q %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	tmpl = template.Must(template.New("report").Parse(
+		`{{define "header"}}<ul>{{end}}{{define "rows"}}{{range .}}<li>{{.Kind}}</li>{{end}}{{end}}{{define "footer"}}</ul>{{.}}{{end}}`))
+	if err := stream.RenderTemplate(events.Parallel(2), &buf, tmpl); err != nil || strings.Count(buf.String(), "<li>") != 3 || !strings.HasSuffix(buf.String(), "</ul>3") {
+		t.Errorf("sections: unexpected %q %v", buf.String(), err)
+	}
+
+	buf.Reset()
+	tmpl = template.Must(template.New("first").Parse(`{{define "rows"}}{{range .}}{{.ID}}{{break}}{{end}}{{end}}`))
+	if err := stream.RenderTemplate(stream.Iterate(event{ID: 7}, func(e event) event { e.ID++; return e }).Limit(1000), &buf, tmpl); err != nil || buf.String() != "7" {
+		t.Errorf("early break: unexpected %q %v", buf.String(), err)
+	}
+
+	tmpl = template.Must(template.New("bad").Parse("{{.Missing}}"))
+	if err := stream.RenderTemplate(events, &buf, tmpl); err == nil {
+		t.Errorf("expect template error")
+	}
+}
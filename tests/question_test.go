@@ -15,6 +15,16 @@ func Test_Question1(t *testing.T) {
 		{Age: 26},
 	}))
 
+	sorted := Question1Sub2([]*Employee{{ID: 3}, {ID: 1}, {ID: 2}})
+	if len(sorted) != 3 {
+		t.Fatalf("question 1-2: expect 3 employees, got %d", len(sorted))
+	}
+	for i, e := range sorted {
+		if e.ID != int64(i+1) {
+			t.Errorf("question 1-2: expect id %d at %d, got %d", i+1, i, e.ID)
+		}
+	}
+
 	results := Question1Sub3([]*Employee{
 		{Age: 18},
 		{Age: 19},
@@ -6,6 +6,7 @@ import (
 	"time"
 
 	"github.com/tr1v3r/stream"
+	"github.com/tr1v3r/stream/types"
 )
 
 // - Q1: 输入 employees，返回 年龄 >22岁 的所有员工，年龄总和
@@ -20,15 +21,8 @@ func Question1Sub1(employees []*Employee) int64 {
 
 // - Q2: - 输入 employees，返回 id 最小的十个员工，按 id 升序排序
 func Question1Sub2(employees []*Employee) []*Employee {
-	return stream.SliceOf[*Employee](employees...).Sort(func(left, right *Employee) int {
-		if left.ID < right.ID {
-			return -1
-		}
-		if left.ID > right.ID {
-			return -1
-		}
-		return 0
-	}).Limit(10).ToSlice()
+	return stream.SliceOf[*Employee](employees...).
+		BottomK(10, types.Comparing(func(e *Employee) int64 { return e.ID }))
 }
 
 // - Q3: - 输入 employees，对于没有手机号为0的数据，随机填写一个
@@ -0,0 +1,183 @@
+package stream
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"time"
+)
+
+var timeType = reflect.TypeOf(time.Time{})
+
+// textField field of struct mapped to a text column or group
+type textField struct {
+	name  string
+	index []int
+}
+
+// fieldsOf return struct type of T and its fields mapped by tag key, T must be struct or pointer to struct
+func fieldsOf[T any](key string) (reflect.Type, []*textField, error) {
+	typ := reflect.TypeOf((*T)(nil)).Elem()
+	if typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	if typ.Kind() != reflect.Struct {
+		return nil, nil, fmt.Errorf("%w: %s element must be struct, got %s", ErrUnsupportType, key, typ)
+	}
+	return typ, textFields(typ, key, nil), nil
+}
+
+// textFields return fields of typ named by tag key like `key:"name"` or field name,
+// fields tagged "-" are ignored and untagged nested structs are flattened
+func textFields(typ reflect.Type, key string, index []int) (fields []*textField) {
+	for i := 0; i < typ.NumField(); i++ {
+		f := typ.Field(i)
+		tag := f.Tag.Get(key)
+		if !f.IsExported() || tag == "-" {
+			continue
+		}
+
+		fieldIndex := append(append([]int(nil), index...), i)
+		if ft := indirectType(f.Type); tag == "" && ft.Kind() == reflect.Struct && ft != timeType {
+			fields = append(fields, textFields(ft, key, fieldIndex)...)
+			continue
+		}
+
+		if tag == "" {
+			tag = f.Name
+		}
+		fields = append(fields, &textField{name: tag, index: fieldIndex})
+	}
+	return fields
+}
+
+// structElem return struct value as T, which is the struct or pointer to it
+func structElem[T any](value reflect.Value) T {
+	if reflect.TypeOf((*T)(nil)).Elem().Kind() == reflect.Pointer {
+		return value.Addr().Interface().(T)
+	}
+	return value.Interface().(T)
+}
+
+// parse set field of struct value from cell, empty cell leave pointers nil
+func (f *textField) parse(value reflect.Value, cell, layout string) error {
+	for _, i := range f.index {
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				if cell == "" {
+					return nil
+				}
+				value.Set(reflect.New(value.Type().Elem()))
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	if value.Kind() == reflect.Pointer {
+		if cell == "" {
+			return nil
+		}
+		value.Set(reflect.New(value.Type().Elem()))
+		value = value.Elem()
+	}
+	return parseText(value, cell, layout)
+}
+
+// format return cell of field of struct value, nil pointers are formatted as empty cell
+func (f *textField) format(value reflect.Value, layout string) (string, error) {
+	value, ok := f.valueOf(value)
+	if !ok {
+		return "", nil
+	}
+	return formatText(value, layout)
+}
+
+// valueOf return field of struct value following pointers, report false if any pointer is nil
+func (f *textField) valueOf(value reflect.Value) (reflect.Value, bool) {
+	for _, i := range f.index {
+		if value.Kind() == reflect.Pointer {
+			if value.IsNil() {
+				return value, false
+			}
+			value = value.Elem()
+		}
+		value = value.Field(i)
+	}
+	if value.Kind() == reflect.Pointer {
+		if value.IsNil() {
+			return value, false
+		}
+		value = value.Elem()
+	}
+	return value, true
+}
+
+func parseText(value reflect.Value, cell, layout string) error {
+	if value.Type() == timeType {
+		t, err := time.Parse(layout, cell)
+		if err == nil {
+			value.Set(reflect.ValueOf(t))
+		}
+		return err
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		value.SetString(cell)
+	case reflect.Bool:
+		b, err := strconv.ParseBool(cell)
+		if err != nil {
+			return err
+		}
+		value.SetBool(b)
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		n, err := strconv.ParseInt(cell, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetInt(n)
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		n, err := strconv.ParseUint(cell, 10, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetUint(n)
+	case reflect.Float32, reflect.Float64:
+		n, err := strconv.ParseFloat(cell, value.Type().Bits())
+		if err != nil {
+			return err
+		}
+		value.SetFloat(n)
+	default:
+		return fmt.Errorf("%w: %s", ErrUnsupportType, value.Type())
+	}
+	return nil
+}
+
+func formatText(value reflect.Value, layout string) (string, error) {
+	if value.Type() == timeType {
+		return value.Interface().(time.Time).Format(layout), nil
+	}
+
+	switch value.Kind() {
+	case reflect.String:
+		return value.String(), nil
+	case reflect.Bool:
+		return strconv.FormatBool(value.Bool()), nil
+	case reflect.Int, reflect.Int8, reflect.Int16, reflect.Int32, reflect.Int64:
+		return strconv.FormatInt(value.Int(), 10), nil
+	case reflect.Uint, reflect.Uint8, reflect.Uint16, reflect.Uint32, reflect.Uint64:
+		return strconv.FormatUint(value.Uint(), 10), nil
+	case reflect.Float32, reflect.Float64:
+		return strconv.FormatFloat(value.Float(), 'g', -1, value.Type().Bits()), nil
+	default:
+		return "", fmt.Errorf("%w: %s", ErrUnsupportType, value.Type())
+	}
+}
+
+func indirectType(typ reflect.Type) reflect.Type {
+	for typ.Kind() == reflect.Pointer {
+		typ = typ.Elem()
+	}
+	return typ
+}
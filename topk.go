@@ -0,0 +1,94 @@
+package stream
+
+import (
+	"container/heap"
+	"sort"
+
+	"github.com/tr1v3r/stream/types"
+)
+
+// TopKByKey return the top k elements of each key extracted by key, elements are in descending order
+func TopKByKey[T any, K comparable](s Streamer[T], k int, key func(T) K, comparator types.Comparator[T]) map[K][]T {
+	groups := fold(s, func() map[K]*boundedHeap[T] { return make(map[K]*boundedHeap[T]) },
+		func(groups map[K]*boundedHeap[T], t T) map[K]*boundedHeap[T] {
+			group := key(t)
+			h, ok := groups[group]
+			if !ok {
+				h = newBoundedHeap(k, comparator)
+				groups[group] = h
+			}
+			h.offer(t)
+			return groups
+		},
+		func(groups, other map[K]*boundedHeap[T]) map[K]*boundedHeap[T] {
+			for group, h := range other {
+				if exists, ok := groups[group]; ok {
+					exists.merge(h)
+				} else {
+					groups[group] = h
+				}
+			}
+			return groups
+		})
+
+	results := make(map[K][]T, len(groups))
+	for group, h := range groups {
+		results[group] = h.sorted()
+	}
+	return results
+}
+
+// topK return the k greatest elements by comparator in descending order with O(k) memory
+func topK[T any](s Streamer[T], k int, comparator types.Comparator[T]) []T {
+	return fold(s, func() *boundedHeap[T] { return newBoundedHeap(k, comparator) },
+		func(h *boundedHeap[T], t T) *boundedHeap[T] { h.offer(t); return h },
+		func(h, other *boundedHeap[T]) *boundedHeap[T] { h.merge(other); return h },
+	).sorted()
+}
+
+// boundedHeap keep the k greatest elements, the least kept element is at the root
+type boundedHeap[T any] struct {
+	k     int
+	cmp   types.Comparator[T]
+	items []T
+}
+
+func newBoundedHeap[T any](k int, comparator types.Comparator[T]) *boundedHeap[T] {
+	return &boundedHeap[T]{k: k, cmp: comparator}
+}
+
+// offer keep t if it is among the k greatest elements
+func (h *boundedHeap[T]) offer(t T) {
+	switch {
+	case h.k <= 0:
+	case len(h.items) < h.k:
+		heap.Push(h, t)
+	case h.cmp(t, h.items[0]) > 0:
+		h.items[0] = t
+		heap.Fix(h, 0)
+	}
+}
+
+// merge offer all elements of other
+func (h *boundedHeap[T]) merge(other *boundedHeap[T]) {
+	for _, t := range other.items {
+		h.offer(t)
+	}
+}
+
+// sorted return kept elements in descending order
+func (h *boundedHeap[T]) sorted() []T {
+	results := append([]T(nil), h.items...)
+	sort.Sort(sort.Reverse(&Sortable[T]{List: results, Cmp: h.cmp}))
+	return results
+}
+
+func (h *boundedHeap[T]) Len() int           { return len(h.items) }
+func (h *boundedHeap[T]) Less(i, j int) bool { return h.cmp(h.items[i], h.items[j]) < 0 }
+func (h *boundedHeap[T]) Swap(i, j int)      { h.items[i], h.items[j] = h.items[j], h.items[i] }
+func (h *boundedHeap[T]) Push(x any)         { h.items = append(h.items, x.(T)) }
+func (h *boundedHeap[T]) Pop() any {
+	item := h.items[len(h.items)-1]
+	h.items = h.items[:len(h.items)-1]
+	return item
+}
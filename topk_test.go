@@ -0,0 +1,32 @@
+package stream_test
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/tr1v3r/stream"
+	"github.com/tr1v3r/stream/types"
+)
+
+func TestTopK(t *testing.T) {
+	data := make([]int, 1000)
+	for i := range data {
+		data[i] = (i * 7919) % 1000
+	}
+	natural := types.Natural[int]()
+
+	if result := stream.SliceOf(data...).TopK(3, natural); !reflect.DeepEqual(result, []int{999, 998, 997}) {
+		t.Errorf("TopK: expect [999 998 997], got %v", result)
+	}
+	if result := stream.SliceOf(data...).Parallel(4).BottomK(3, natural); !reflect.DeepEqual(result, []int{0, 1, 2}) {
+		t.Errorf("parallel BottomK: expect [0 1 2], got %v", result)
+	}
+	if result := stream.SliceOf(1, 2).TopK(5, natural); !reflect.DeepEqual(result, []int{2, 1}) {
+		t.Errorf("TopK: expect [2 1], got %v", result)
+	}
+
+	groups := stream.TopKByKey(stream.SliceOf(data...).Parallel(4), 2, func(i int) int { return i % 2 }, natural)
+	if expect := map[int][]int{0: {998, 996}, 1: {999, 997}}; !reflect.DeepEqual(groups, expect) {
+		t.Errorf("TopKByKey: expect %v, got %v", expect, groups)
+	}
+}
@@ -0,0 +1,56 @@
+package types
+
+import "cmp"
+
+// Natural return comparator for ordered type in natural order
+func Natural[T cmp.Ordered]() Comparator[T] { return cmp.Compare[T] }
+
+// Comparing return comparator comparing ordered keys extracted by key
+func Comparing[T any, K cmp.Ordered](key func(T) K) Comparator[T] {
+	return func(left, right T) int { return cmp.Compare(key(left), key(right)) }
+}
+
+// ComparingWith return comparator comparing keys extracted by key with comparator
+func ComparingWith[T, K any](key func(T) K, comparator Comparator[K]) Comparator[T] {
+	return func(left, right T) int { return comparator(key(left), key(right)) }
+}
+
+// ThenComparing return comparator which use next to compare when c considers left equal to right
+func (c Comparator[T]) ThenComparing(next Comparator[T]) Comparator[T] {
+	return func(left, right T) int {
+		if result := c(left, right); result != 0 {
+			return result
+		}
+		return next(left, right)
+	}
+}
+
+// Reversed return comparator in reverse order of c
+func (c Comparator[T]) Reversed() Comparator[T] {
+	return func(left, right T) int { return c(right, left) }
+}
+
+// NullsFirst return comparator for pointers, nil is less than non-nil, non-nil values are compared with comparator
+func NullsFirst[T any](comparator Comparator[T]) Comparator[*T] {
+	return nullsComparator(comparator, -1)
+}
+
+// NullsLast return comparator for pointers, nil is greater than non-nil, non-nil values are compared with comparator
+func NullsLast[T any](comparator Comparator[T]) Comparator[*T] {
+	return nullsComparator(comparator, 1)
+}
+
+func nullsComparator[T any](comparator Comparator[T], nilResult int) Comparator[*T] {
+	return func(left, right *T) int {
+		switch {
+		case left == nil && right == nil:
+			return 0
+		case left == nil:
+			return nilResult
+		case right == nil:
+			return -nilResult
+		default:
+			return comparator(*left, *right)
+		}
+	}
+}
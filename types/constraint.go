@@ -0,0 +1,18 @@
+package types
+
+type (
+	// Signed signed integer types
+	Signed interface {
+		~int | ~int8 | ~int16 | ~int32 | ~int64
+	}
+	// Unsigned unsigned integer types
+	Unsigned interface {
+		~uint | ~uint8 | ~uint16 | ~uint32 | ~uint64 | ~uintptr
+	}
+	// Integer integer types
+	Integer interface{ Signed | Unsigned }
+	// Float floating-point types
+	Float interface{ ~float32 | ~float64 }
+	// Number integer and floating-point types
+	Number interface{ Integer | Float }
+)